@@ -0,0 +1,481 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to an outgoing request
+// Authenticators are set on APICallerConfig and applied to every REST, form-urlencoded
+// and SOAP request, and to gRPC calls and streams as outgoing metadata.
+// Implementations must be safe for concurrent use.
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *AuthRequest) error
+}
+
+// AuthRequest describes the request being authenticated
+// Authenticators add credentials by setting entries in Header.
+type AuthRequest struct {
+	// Method is the HTTP method (POST for gRPC)
+	Method string
+	// URL is the request URL; for gRPC the path is the full method name
+	URL *url.URL
+	// Header is the request header (gRPC metadata for gRPC calls)
+	Header http.Header
+	// HTTPClient has the CA, client certificate and proxy settings of the caller
+	// for authenticators that make their own requests, such as token fetches
+	HTTPClient *http.Client
+
+	body    func() ([]byte, error)
+	payload []byte
+	loaded  bool
+}
+
+// Body returns the request payload, buffering it on first use
+// For gRPC calls this is the wire-encoded request message; streams have no body.
+func (r *AuthRequest) Body() ([]byte, error) {
+	if r.loaded {
+		return r.payload, nil
+	}
+	r.loaded = true
+	if r.body == nil {
+		return nil, nil
+	}
+	payload, err := r.body()
+	if err != nil {
+		return nil, err
+	}
+	r.payload = payload
+	return payload, nil
+}
+
+// AuthError is returned when an Authenticator fails to produce credentials
+type AuthError struct {
+	// FriendlyName is the name of the endpoint being called
+	FriendlyName string
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed for endpoint '%s': %v", e.FriendlyName, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// authenticateHTTPRequest applies auth to req, buffering a streamed body if it is read
+func authenticateHTTPRequest(auth Authenticator, name string, client *http.Client, req *http.Request) error {
+	if auth == nil {
+		return nil
+	}
+	authReq := &AuthRequest{
+		Method:     req.Method,
+		URL:        req.URL,
+		Header:     req.Header,
+		HTTPClient: client,
+		body: func() ([]byte, error) {
+			if req.Body == nil || req.Body == http.NoBody {
+				return nil, nil
+			}
+			if req.GetBody != nil {
+				rc, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+			data, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(data))
+			req.ContentLength = int64(len(data))
+			return data, nil
+		},
+	}
+	if err := auth.Authenticate(req.Context(), authReq); err != nil {
+		return &AuthError{FriendlyName: name, Err: err}
+	}
+	return nil
+}
+
+// authenticateGRPC applies auth to a gRPC call and returns the headers to send as metadata
+func authenticateGRPC(ctx context.Context, auth Authenticator, endpoint Endpoint, client *http.Client, method string, headers map[string]string, body func() ([]byte, error)) (map[string]string, error) {
+	if auth == nil {
+		return headers, nil
+	}
+	header := make(http.Header, len(headers))
+	for key, value := range headers {
+		header.Set(key, value)
+	}
+	authReq := &AuthRequest{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Scheme: "grpc", Host: endpoint.URL, Path: method},
+		Header:     header,
+		HTTPClient: client,
+		body:       body,
+	}
+	if err := auth.Authenticate(ctx, authReq); err != nil {
+		return nil, &AuthError{FriendlyName: endpoint.FriendlyName, Err: err}
+	}
+
+	merged := make(map[string]string, len(header))
+	for key, values := range header {
+		if len(values) > 0 {
+			merged[strings.ToLower(key)] = values[0]
+		}
+	}
+	return merged, nil
+}
+
+// newAuthHTTPClient returns the client authenticators use for their own requests,
+// with the CA, client certificate and proxy of config
+// UnixSocket and the HTTP/2 mode apply to the endpoint only, so token hosts are
+// dialed directly. Returns nil if config has no Authenticator.
+func newAuthHTTPClient(config *APICallerConfig, tlsConfig *tls.Config) (*http.Client, error) {
+	if config.Authenticator == nil {
+		return nil, nil
+	}
+	transport, err := buildHTTPTransport(config, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+	transport.DialContext = dialer.DialContext
+	transport.Protocols = nil
+	transport.HTTP2 = nil
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}, nil
+}
+
+// invalidateCredentials discards cached credentials after the server rejected them
+func invalidateCredentials(auth Authenticator) {
+	if inv, ok := auth.(interface{ Invalidate() }); ok {
+		inv.Invalidate()
+	}
+}
+
+// authenticatorKey identifies an Authenticator for caller caching
+// Pointer authenticators are keyed by identity so cached tokens are not part of the key.
+func authenticatorKey(auth Authenticator) string {
+	if auth == nil {
+		return ""
+	}
+	v := reflect.ValueOf(auth)
+	if v.Kind() == reflect.Pointer {
+		return fmt.Sprintf("%T@%x", auth, v.Pointer())
+	}
+	return fmt.Sprintf("%T=%x", auth, sha256.Sum256(fmt.Appendf(nil, "%#v", auth)))
+}
+
+// BearerTokenAuth sends a static bearer token
+type BearerTokenAuth struct {
+	Token string
+}
+
+// Authenticate implements Authenticator
+func (a *BearerTokenAuth) Authenticate(ctx context.Context, req *AuthRequest) error {
+	if a.Token == "" {
+		return errors.New("bearer token is empty")
+	}
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// BasicAuth sends HTTP basic credentials
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate implements Authenticator
+func (a *BasicAuth) Authenticate(ctx context.Context, req *AuthRequest) error {
+	credentials := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+	req.Header.Set("Authorization", "Basic "+credentials)
+	return nil
+}
+
+// HMACAuth signs each request with a shared secret
+// The string to sign is:
+//
+//	METHOD \n PATH?QUERY \n TIMESTAMP \n HEX(SHA256(BODY))
+//
+// followed by "\n name:value" for each entry in SignedHeaders. The signature is sent as
+//
+//	Authorization: HMAC-SHA256 keyId="<KeyID>",timestamp="<unix>",signature="<base64>"
+type HMACAuth struct {
+	// KeyID identifies the secret to the server
+	KeyID string
+	// Secret is the shared signing key
+	Secret []byte
+	// SignedHeaders are additional request headers included in the signature
+	SignedHeaders []string
+	// Header is the header carrying the signature
+	// If unset, defaults to "Authorization"
+	Header string
+	// Hash is the HMAC hash function
+	// If unset, defaults to sha256.New
+	Hash func() hash.Hash
+
+	now func() time.Time
+}
+
+// Authenticate implements Authenticator
+func (a *HMACAuth) Authenticate(ctx context.Context, req *AuthRequest) error {
+	if len(a.Secret) == 0 {
+		return errors.New("HMAC secret is empty")
+	}
+	body, err := req.Body()
+	if err != nil {
+		return fmt.Errorf("failed to read request body for signing: %w", err)
+	}
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+
+	stringToSign := a.StringToSign(req, timestamp, body)
+	hashFunc := a.Hash
+	if hashFunc == nil {
+		hashFunc = sha256.New
+	}
+	mac := hmac.New(hashFunc, a.Secret)
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	header := a.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	req.Header.Set(header, fmt.Sprintf(`HMAC-SHA256 keyId="%s",timestamp="%s",signature="%s"`, a.KeyID, timestamp, signature))
+	return nil
+}
+
+// StringToSign returns the canonical string signed for req
+// Servers can use it to verify signatures.
+func (a *HMACAuth) StringToSign(req *AuthRequest, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	var sb strings.Builder
+	sb.WriteString(strings.ToUpper(req.Method))
+	sb.WriteString("\n")
+	if req.URL != nil {
+		sb.WriteString(req.URL.EscapedPath())
+		if req.URL.RawQuery != "" {
+			sb.WriteString("?" + req.URL.RawQuery)
+		}
+	}
+	sb.WriteString("\n" + timestamp)
+	sb.WriteString("\n" + hex.EncodeToString(bodyHash[:]))
+	for _, name := range a.SignedHeaders {
+		sb.WriteString("\n" + strings.ToLower(name) + ":" + strings.TrimSpace(req.Header.Get(name)))
+	}
+	return sb.String()
+}
+
+// OAuth2AuthStyle selects how client credentials are sent to the token endpoint
+type OAuth2AuthStyle int
+
+const (
+	// OAuth2AuthStyleHeader sends the client credentials as HTTP basic auth
+	OAuth2AuthStyleHeader OAuth2AuthStyle = iota
+	// OAuth2AuthStyleParams sends client_id and client_secret in the form body
+	OAuth2AuthStyleParams
+)
+
+// OAuth2ClientCredentials obtains access tokens with the OAuth2 client credentials grant
+// Tokens are cached and refreshed shortly before they expire, and discarded when a
+// request is rejected with HTTP 401 so the next call fetches a new token.
+type OAuth2ClientCredentials struct {
+	// TokenURL is the token endpoint
+	TokenURL string
+	// ClientID is the OAuth2 client identifier
+	ClientID string
+	// ClientSecret is the OAuth2 client secret
+	ClientSecret string
+	// Scopes are the requested scopes
+	Scopes []string
+	// EndpointParams are additional form parameters for the token request (e.g. audience)
+	EndpointParams url.Values
+	// AuthStyle selects how client credentials are sent (default: basic auth header)
+	AuthStyle OAuth2AuthStyle
+	// ExpiryDelta refreshes the token this long before it expires
+	// If set to 0 or unset, defaults to 30 seconds
+	ExpiryDelta time.Duration
+	// HTTPClient is used for token requests
+	// If unset, the client of the calling endpoint is used, with its CA, client
+	// certificate and proxy settings; http.DefaultClient is used by Token
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	tokType string
+	expiry  time.Time
+	now     func() time.Time
+}
+
+// oauth2TokenResponse is the token endpoint response
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Authenticate implements Authenticator
+func (a *OAuth2ClientCredentials) Authenticate(ctx context.Context, req *AuthRequest) error {
+	tokenType, token, err := a.tokenWith(ctx, req.HTTPClient)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", tokenType+" "+token)
+	return nil
+}
+
+// Token returns a valid access token and its type, fetching a new one if needed
+func (a *OAuth2ClientCredentials) Token(ctx context.Context) (string, string, error) {
+	return a.tokenWith(ctx, nil)
+}
+
+// tokenWith returns a valid access token and its type, fetching a new one with
+// HTTPClient, else client, else http.DefaultClient
+func (a *OAuth2ClientCredentials) tokenWith(ctx context.Context, client *http.Client) (string, string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	delta := a.ExpiryDelta
+	if delta <= 0 {
+		delta = 30 * time.Second
+	}
+
+	if a.token != "" && (a.expiry.IsZero() || now().Add(delta).Before(a.expiry)) {
+		return a.tokType, a.token, nil
+	}
+
+	tokenResp, err := a.fetchToken(ctx, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	a.token = tokenResp.AccessToken
+	a.tokType = tokenResp.TokenType
+	if a.tokType == "" || strings.EqualFold(a.tokType, "bearer") {
+		a.tokType = "Bearer"
+	}
+	a.expiry = time.Time{}
+	if tokenResp.ExpiresIn > 0 {
+		a.expiry = now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	return a.tokType, a.token, nil
+}
+
+// Invalidate discards the cached token
+func (a *OAuth2ClientCredentials) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+	a.expiry = time.Time{}
+}
+
+// fetchToken requests a new token from the token endpoint
+func (a *OAuth2ClientCredentials) fetchToken(ctx context.Context, client *http.Client) (*oauth2TokenResponse, error) {
+	if a.TokenURL == "" {
+		return nil, errors.New("OAuth2 token URL is required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	form := url.Values{}
+	for key, values := range a.EndpointParams {
+		form[key] = append([]string(nil), values...)
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(a.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.Scopes, " "))
+	}
+	if a.AuthStyle == OAuth2AuthStyleParams {
+		form.Set("client_id", a.ClientID)
+		form.Set("client_secret", a.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if a.AuthStyle == OAuth2AuthStyleHeader {
+		req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(a.ClientSecret))
+	}
+
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = client
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tokenResp oauth2TokenResponse
+	jsonErr := json.Unmarshal(data, &tokenResp)
+	if resp.StatusCode >= 400 || tokenResp.Error != "" {
+		if tokenResp.Error != "" {
+			return nil, fmt.Errorf("token request failed: HTTP %d: %s %s", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token request failed: HTTP %d: %s", resp.StatusCode, string(data))
+	}
+	if jsonErr != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", jsonErr)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, errors.New("token response did not contain an access_token")
+	}
+	return &tokenResp, nil
+}
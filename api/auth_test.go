@@ -0,0 +1,403 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/tap"
+)
+
+// TestOAuth2ClientCredentials verifies tokens are fetched once, cached, refreshed and invalidated on 401
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := tokenRequests.Add(1)
+		user, pass, _ := r.BasicAuth()
+		r.ParseForm()
+		if user != "client" || pass != "secret" || r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	var mu sync.Mutex
+	var seen []string
+	reject := false
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		if reject {
+			reject = false
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer apiServer.Close()
+
+	now := time.Now()
+	auth := &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+		now:          func() time.Time { return now },
+	}
+	endpoint := Endpoint{
+		FriendlyName: "Test OAuth2",
+		URL:          apiServer.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+		Config:       &APICallerConfig{Authenticator: auth},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	for range 2 {
+		if _, err := endpoint.Call(nil); err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+	}
+	if tokenRequests.Load() != 1 {
+		t.Errorf("Expected 1 token request, got %d", tokenRequests.Load())
+	}
+
+	// Refresh shortly before expiry
+	now = now.Add(3590 * time.Second)
+	if _, err := endpoint.Call(nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	// A 401 discards the cached token
+	mu.Lock()
+	reject = true
+	mu.Unlock()
+	endpoint.Call(nil)
+	if _, err := endpoint.Call(nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"Bearer token-1", "Bearer token-1", "Bearer token-2", "Bearer token-2", "Bearer token-3"}
+	if strings.Join(seen, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected authorization headers %v, got %v", expected, seen)
+	}
+}
+
+// TestOAuth2TokenError verifies token endpoint errors are returned as *AuthError
+func TestOAuth2TokenError(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_scope","error_description":"unknown scope"}`))
+	}))
+	defer tokenServer.Close()
+
+	var apiCalls atomic.Int32
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiCalls.Add(1)
+	}))
+	defer apiServer.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test OAuth2 Error",
+		URL:          apiServer.URL,
+		Type:         EndpointTypeREST,
+		Config: &APICallerConfig{
+			Authenticator: &OAuth2ClientCredentials{TokenURL: tokenServer.URL, AuthStyle: OAuth2AuthStyleParams},
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	_, err := endpoint.Call(nil)
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected *AuthError, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "invalid_scope") {
+		t.Errorf("Expected token error in message, got %v", err)
+	}
+	if apiCalls.Load() != 0 {
+		t.Errorf("Expected no API calls without credentials, got %d", apiCalls.Load())
+	}
+}
+
+// TestStaticAuthenticators verifies bearer and basic credentials on REST, form and SOAP endpoints
+func TestStaticAuthenticators(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if r.Header.Get("SOAPAction") != "" {
+			w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><PingResponse/></soap:Body></soap:Envelope>`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	bearer := &APICallerConfig{Authenticator: &BearerTokenAuth{Token: "abc"}}
+	basic := &APICallerConfig{Authenticator: &BasicAuth{Username: "user", Password: "pass"}}
+	endpoints := []Endpoint{
+		{FriendlyName: "Auth REST", URL: server.URL, Type: EndpointTypeREST, Config: bearer},
+		{FriendlyName: "Auth Form", URL: server.URL, Type: EndpointTypeFormURLEncoded, Config: basic},
+		{FriendlyName: "Auth SOAP", URL: server.URL, Type: EndpointTypeSOAP, Config: bearer},
+	}
+	for _, endpoint := range endpoints {
+		defer RemoveCallerFromCache(endpoint, endpoint.Config)
+		if _, err := endpoint.Call(map[string]any{"method": "Ping", "body": map[string]any{"a": "b"}}); err != nil {
+			t.Fatalf("%s call failed: %v", endpoint.FriendlyName, err)
+		}
+	}
+
+	expected := []string{"Bearer abc", "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass")), "Bearer abc"}
+	if strings.Join(seen, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected authorization headers %v, got %v", expected, seen)
+	}
+}
+
+// TestHMACAuth verifies the request signature can be verified by the server
+func TestHMACAuth(t *testing.T) {
+	secret := []byte("shared-secret")
+	auth := &HMACAuth{
+		KeyID:         "key-1",
+		Secret:        secret,
+		SignedHeaders: []string{"X-Tenant"},
+		now:           func() time.Time { return time.Unix(1700000000, 0) },
+	}
+
+	var verified atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := fmt.Sprintf(`HMAC-SHA256 keyId="key-1",timestamp="1700000000",signature="%s"`,
+			sign(secret, auth.StringToSign(&AuthRequest{Method: r.Method, URL: r.URL, Header: r.Header}, "1700000000", body)))
+		verified.Store(r.Header.Get("Authorization") == expected && string(body) == `{"amount":5}`)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test HMAC",
+		URL:          server.URL + "/payments?dry=1",
+		Type:         EndpointTypeREST,
+		Config:       &APICallerConfig{Authenticator: auth},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	_, err := endpoint.Call(map[string]any{
+		"method":  "POST",
+		"headers": map[string]string{"X-Tenant": "acme"},
+		"body":    strings.NewReader(`{"amount":5}`),
+	})
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if !verified.Load() {
+		t.Error("Server could not verify the HMAC signature")
+	}
+}
+
+func sign(secret []byte, stringToSign string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// TestGRPCAuthenticator verifies credentials are sent as gRPC metadata
+func TestGRPCAuthenticator(t *testing.T) {
+	var authorization atomic.Value
+	server, port, err := startTestAuthServer(func(md metadata.MD) {
+		if info := firstMetadataValue(md, "authorization"); info != "" {
+			authorization.Store(info)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test gRPC Auth",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/meta.MetaService/Check",
+		Timeout:      10 * time.Second,
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+			Authenticator:      &BearerTokenAuth{Token: "grpc-token"},
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	if _, err := endpoint.Call(map[string]any{"body": map[string]any{"name": "ok"}}); err != nil {
+		t.Fatalf("gRPC call failed: %v", err)
+	}
+	if authorization.Load() != "Bearer grpc-token" {
+		t.Errorf("Expected bearer metadata, got %v", authorization.Load())
+	}
+}
+
+// startTestAuthServer starts the meta service with a tap observing incoming metadata
+func startTestAuthServer(observe func(md metadata.MD)) (*grpc.Server, string, error) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen: %w", err)
+	}
+
+	port := fmt.Sprintf("%d", lis.Addr().(*net.TCPAddr).Port)
+
+	server := grpc.NewServer(grpc.InTapHandle(func(ctx context.Context, info *tap.Info) (context.Context, error) {
+		observe(info.Header)
+		return ctx, nil
+	}))
+	if err := registerTestMetaService(server); err != nil {
+		return nil, "", fmt.Errorf("failed to register service: %w", err)
+	}
+	reflection.Register(server)
+
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			log.Printf("Test gRPC server stopped: %v", err)
+		}
+	}()
+
+	return server, port, nil
+}
+
+// TestOAuth2EndpointTLS verifies tokens are fetched with the CA of the endpoint configuration
+func TestOAuth2EndpointTLS(t *testing.T) {
+	tokenServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"tls-token","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	var authorization atomic.Value
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer apiServer.Close()
+
+	auth := &OAuth2ClientCredentials{TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "secret"}
+	if _, _, err := auth.Token(context.Background()); err == nil {
+		t.Fatal("Expected http.DefaultClient not to trust the test CA")
+	}
+
+	endpoint := Endpoint{
+		FriendlyName: "Test OAuth2 TLS",
+		URL:          apiServer.URL,
+		Type:         EndpointTypeREST,
+		Config: &APICallerConfig{
+			CACertData:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tokenServer.Certificate().Raw}),
+			Authenticator: auth,
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	if _, err := endpoint.Call(nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if authorization.Load() != "Bearer tls-token" {
+		t.Errorf("Expected the token fetched over TLS, got %v", authorization.Load())
+	}
+}
+
+// TestOAuth2GRPCUnauthenticated verifies tokens rejected by gRPC calls and streams are discarded
+func TestOAuth2GRPCUnauthenticated(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, tokenRequests.Add(1))
+	}))
+	defer tokenServer.Close()
+
+	// token-1 is revoked for unary calls and token-2 for the Count stream
+	revoked := map[string]string{"/meta.MetaService/Check": "Bearer token-1", "/counter.CounterService/Count": "Bearer token-2"}
+	server := grpc.NewServer(grpc.InTapHandle(func(ctx context.Context, info *tap.Info) (context.Context, error) {
+		if values := info.Header.Get("authorization"); len(values) == 0 || values[0] == revoked[info.FullMethodName] {
+			return ctx, status.Error(codes.Unauthenticated, "token revoked")
+		}
+		return ctx, nil
+	}))
+	if err := registerTestMetaService(server); err != nil {
+		t.Fatalf("Failed to register service: %v", err)
+	}
+	if err := registerTestCounterService(server); err != nil {
+		t.Fatalf("Failed to register service: %v", err)
+	}
+	reflection.Register(server)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve(lis)
+	defer server.Stop()
+
+	config := &APICallerConfig{
+		InsecureSkipVerify: true,
+		Authenticator:      &OAuth2ClientCredentials{TokenURL: tokenServer.URL},
+	}
+	unary := Endpoint{FriendlyName: "Test OAuth2 gRPC", URL: lis.Addr().String(), Type: EndpointTypeGRPC, MethodName: "/meta.MetaService/Check", Config: config}
+	defer RemoveCallerFromCache(unary, config)
+
+	if _, err := unary.Call(map[string]any{"body": map[string]any{"name": "ok"}}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated with the revoked token, got %v", err)
+	}
+	if _, err := unary.Call(map[string]any{"body": map[string]any{"name": "ok"}}); err != nil {
+		t.Fatalf("Expected a new token after Unauthenticated, got %v", err)
+	}
+
+	streaming := Endpoint{FriendlyName: "Test OAuth2 gRPC Stream", URL: lis.Addr().String(), Type: EndpointTypeGRPC, MethodName: "/counter.CounterService/Count", Config: config}
+	defer RemoveCallerFromCache(streaming, config)
+	params := map[string]any{"body": map[string]any{"value": 2}}
+
+	drain := func() error {
+		stream, err := streaming.Stream(context.Background(), params)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		for _, err := range stream.Messages() {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := drain(); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated on the stream with the revoked token, got %v", err)
+	}
+	if err := drain(); err != nil {
+		t.Fatalf("Expected a new token after the stream was rejected, got %v", err)
+	}
+	if tokenRequests.Load() != 3 {
+		t.Errorf("Expected 3 token requests, got %d", tokenRequests.Load())
+	}
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errCallerClosed is returned for calls on a caller closed after eviction
+var errCallerClosed = errors.New("API caller was evicted from the cache and closed")
+
+// CallerCachePolicy bounds the global caller cache
+// Evicted callers are removed from the cache immediately and closed once the
+// calls, streamed bodies and gRPC streams already using them have finished.
+type CallerCachePolicy struct {
+	// MaxSize is the maximum number of cached callers; the least recently used is evicted
+	// If set to 0 or unset, the cache size is not limited
+	MaxSize int
+	// IdleTTL evicts callers that have not been used for this long
+	// If set to 0 or unset, idle callers are never evicted
+	IdleTTL time.Duration
+}
+
+var (
+	// callerCachePolicy is the active cache policy, guarded by callerCacheMutex
+	callerCachePolicy CallerCachePolicy
+	// callerCacheJanitorStop stops the idle eviction goroutine, guarded by callerCacheMutex
+	callerCacheJanitorStop chan struct{}
+)
+
+// SetCallerCachePolicy sets the eviction policy of the global caller cache
+// The policy is applied immediately and, when IdleTTL is set, by a background
+// goroutine that checks for idle callers every IdleTTL/2.
+func SetCallerCachePolicy(policy CallerCachePolicy) {
+	callerCacheMutex.Lock()
+	defer callerCacheMutex.Unlock()
+
+	callerCachePolicy = policy
+	if callerCacheJanitorStop != nil {
+		close(callerCacheJanitorStop)
+		callerCacheJanitorStop = nil
+	}
+	if policy.IdleTTL > 0 {
+		callerCacheJanitorStop = make(chan struct{})
+		go runCallerCacheJanitor(policy.IdleTTL/2, callerCacheJanitorStop)
+	}
+
+	evictCallersLocked(time.Now())
+}
+
+// CallerCacheSize returns the number of cached callers
+func CallerCacheSize() int {
+	callerCacheMutex.RLock()
+	defer callerCacheMutex.RUnlock()
+	return len(callerCache)
+}
+
+// runCallerCacheJanitor periodically evicts idle callers until stop is closed
+func runCallerCacheJanitor(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(max(interval, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			callerCacheMutex.Lock()
+			evictCallersLocked(now)
+			callerCacheMutex.Unlock()
+		}
+	}
+}
+
+// evictCallersLocked removes idle and excess callers according to the policy
+// callerCacheMutex must be held for writing.
+func evictCallersLocked(now time.Time) {
+	policy := callerCachePolicy
+	var evicted []*APICaller
+
+	if policy.IdleTTL > 0 {
+		for key, caller := range callerCache {
+			if now.Sub(caller.lastUsedTime()) > policy.IdleTTL {
+				evicted = append(evicted, caller)
+				delete(callerCache, key)
+			}
+		}
+	}
+
+	for policy.MaxSize > 0 && len(callerCache) > policy.MaxSize {
+		var oldestKey string
+		var oldest *APICaller
+		for key, caller := range callerCache {
+			if oldest == nil || caller.lastUsedTime().Before(oldest.lastUsedTime()) {
+				oldestKey, oldest = key, caller
+			}
+		}
+		evicted = append(evicted, oldest)
+		delete(callerCache, oldestKey)
+	}
+
+	for _, caller := range evicted {
+		caller.retire()
+	}
+}
+
+// acquireAPICaller returns the cached caller for endpoint and config, in use
+// until release is called
+// A caller evicted and closed between lookup and use is replaced by a new one.
+func acquireAPICaller(endpoint Endpoint, config *APICallerConfig) (*APICaller, func(), error) {
+	for {
+		caller, err := GetOrCreateAPICaller(endpoint, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		if release, ok := caller.acquire(); ok {
+			return caller, release, nil
+		}
+	}
+}
+
+// acquire marks the caller in use until release is called
+// It returns false if the caller was retired and has already been closed.
+func (ac *APICaller) acquire() (release func(), ok bool) {
+	ac.useMutex.Lock()
+	defer ac.useMutex.Unlock()
+	if ac.closed {
+		return nil, false
+	}
+	ac.inUse++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ac.useMutex.Lock()
+			ac.inUse--
+			closing := ac.closeIfUnusedLocked()
+			ac.useMutex.Unlock()
+			if closing {
+				ac.Close()
+			}
+		})
+	}, true
+}
+
+// retire marks the caller removed from the cache and closes it once it is no longer in use
+func (ac *APICaller) retire() {
+	ac.useMutex.Lock()
+	ac.retired = true
+	closing := ac.closeIfUnusedLocked()
+	ac.useMutex.Unlock()
+	if closing {
+		ac.Close()
+	}
+}
+
+// closeIfUnusedLocked reports whether a retired caller is unused and marks it closed
+// useMutex must be held.
+func (ac *APICaller) closeIfUnusedLocked() bool {
+	if !ac.retired || ac.closed || ac.inUse > 0 {
+		return false
+	}
+	ac.closed = true
+	return true
+}
+
+// touch records that the caller was used
+func (ac *APICaller) touch() {
+	ac.lastUsed.Store(time.Now().UnixNano())
+}
+
+// lastUsedTime returns when the caller was last used
+func (ac *APICaller) lastUsedTime() time.Time {
+	return time.Unix(0, ac.lastUsed.Load())
+}
+
+// RotateCallerCertificate hot-swaps the client certificate of the cached caller
+// for endpoint and config, without closing it. The caller is re-keyed under the
+// returned config (a copy of config with the new certificate data), so calls made
+// with it keep using the existing connections. Set it as the endpoint Config.
+// If no caller is cached yet, the returned config simply creates one on first use.
+// An error is returned if the cached caller has no certificate to rotate, such as
+// a gRPC caller without TLS.
+func RotateCallerCertificate(endpoint Endpoint, config *APICallerConfig, certData, keyData []byte) (*APICallerConfig, error) {
+	if config == nil {
+		config = &APICallerConfig{}
+	}
+	if len(certData) == 0 || len(keyData) == 0 {
+		return nil, errors.New("certificate and key data are required")
+	}
+
+	rotated := *config
+	rotated.TLSCertData = certData
+	rotated.TLSKeyData = keyData
+
+	oldKey := generateCacheKey(endpoint, config)
+	newKey := generateCacheKey(endpoint, &rotated)
+
+	callerCacheMutex.Lock()
+	defer callerCacheMutex.Unlock()
+
+	caller, exists := callerCache[oldKey]
+	if !exists {
+		return &rotated, nil
+	}
+	if err := caller.UpdateClientCertificate(certData, keyData); err != nil {
+		return nil, err
+	}
+	caller.config = &rotated
+
+	if oldKey != newKey {
+		delete(callerCache, oldKey)
+		if existing, ok := callerCache[newKey]; ok && existing != caller {
+			// A caller for the rotated config already exists; retire it
+			existing.retire()
+		}
+		caller.cacheKey = newKey
+		callerCache[newKey] = caller
+	}
+	return &rotated, nil
+}
@@ -1,6 +1,16 @@
 package api_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -245,3 +255,189 @@ func TestEndpointTimeout(t *testing.T) {
 		t.Logf("✓ Default timeout (30s) succeeded")
 	}
 }
+
+// TestCallerCacheMaxSize verifies the least recently used caller is evicted
+func TestCallerCacheMaxSize(t *testing.T) {
+	api.ClearCallerCache()
+	api.SetCallerCachePolicy(api.CallerCachePolicy{MaxSize: 2})
+	defer api.SetCallerCachePolicy(api.CallerCachePolicy{})
+	defer api.ClearCallerCache()
+
+	newEndpoint := func(path string) api.Endpoint {
+		return api.Endpoint{
+			FriendlyName: "LRU API",
+			URL:          "https://api.example.com/" + path,
+			Type:         api.EndpointTypeREST,
+		}
+	}
+	config := &api.APICallerConfig{}
+
+	first, err := api.NewAPICaller(newEndpoint("first"), config)
+	if err != nil {
+		t.Fatalf("Failed to create caller: %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if _, err := api.NewAPICaller(newEndpoint("second"), config); err != nil {
+		t.Fatalf("Failed to create caller: %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// Using the first caller again makes the second the least recently used
+	if again, _ := api.NewAPICaller(newEndpoint("first"), config); again != first {
+		t.Fatal("Expected the first caller to still be cached")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if _, err := api.NewAPICaller(newEndpoint("third"), config); err != nil {
+		t.Fatalf("Failed to create caller: %v", err)
+	}
+
+	if size := api.CallerCacheSize(); size != 2 {
+		t.Fatalf("Expected cache size 2, got %d", size)
+	}
+	if again, _ := api.NewAPICaller(newEndpoint("first"), config); again != first {
+		t.Error("Expected the recently used first caller to survive eviction")
+	}
+}
+
+// TestCallerCacheIdleTTL verifies idle callers are evicted in the background
+func TestCallerCacheIdleTTL(t *testing.T) {
+	api.ClearCallerCache()
+	api.SetCallerCachePolicy(api.CallerCachePolicy{IdleTTL: 20 * time.Millisecond})
+	defer api.SetCallerCachePolicy(api.CallerCachePolicy{})
+	defer api.ClearCallerCache()
+
+	endpoint := api.Endpoint{
+		FriendlyName: "Idle API",
+		URL:          "https://api.example.com/idle",
+		Type:         api.EndpointTypeREST,
+	}
+	if _, err := api.NewAPICaller(endpoint, &api.APICallerConfig{}); err != nil {
+		t.Fatalf("Failed to create caller: %v", err)
+	}
+	if size := api.CallerCacheSize(); size != 1 {
+		t.Fatalf("Expected cache size 1, got %d", size)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for api.CallerCacheSize() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected idle caller to be evicted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestRotateCallerCertificate verifies a cached caller presents a rotated client certificate
+func TestRotateCallerCertificate(t *testing.T) {
+	api.ClearCallerCache()
+	defer api.ClearCallerCache()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			http.Error(w, "no client certificate", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	firstCert, firstKey := generateClientCertificate(t, "first")
+	secondCert, secondKey := generateClientCertificate(t, "second")
+
+	endpoint := api.Endpoint{
+		FriendlyName: "mTLS API",
+		URL:          server.URL,
+		Type:         api.EndpointTypeREST,
+		Timeout:      5 * time.Second,
+	}
+	config := &api.APICallerConfig{
+		InsecureSkipVerify: true,
+		TLSCertData:        firstCert,
+		TLSKeyData:         firstKey,
+	}
+
+	caller, err := api.GetOrCreateAPICaller(endpoint, config)
+	if err != nil {
+		t.Fatalf("Failed to create caller: %v", err)
+	}
+	assertPeerName := func(caller *api.APICaller, expected string) {
+		t.Helper()
+		response, err := caller.Call(&api.CallOptions{Method: http.MethodGet})
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if body := string(response.Body.([]byte)); body != expected {
+			t.Fatalf("Expected client certificate '%s', got '%s'", expected, body)
+		}
+	}
+	assertPeerName(caller, "first")
+
+	rotated, err := api.RotateCallerCertificate(endpoint, config, secondCert, secondKey)
+	if err != nil {
+		t.Fatalf("Failed to rotate certificate: %v", err)
+	}
+	rotatedCaller, err := api.GetOrCreateAPICaller(endpoint, rotated)
+	if err != nil {
+		t.Fatalf("Failed to get rotated caller: %v", err)
+	}
+	if rotatedCaller != caller {
+		t.Fatal("Expected rotation to keep the cached caller")
+	}
+	if api.CallerCacheSize() != 1 {
+		t.Errorf("Expected a single cached caller, got %d", api.CallerCacheSize())
+	}
+	assertPeerName(caller, "second")
+
+	// Rotating back in place works on the caller directly
+	if err := caller.UpdateClientCertificate(firstCert, firstKey); err != nil {
+		t.Fatalf("Failed to update certificate: %v", err)
+	}
+	assertPeerName(caller, "first")
+
+	if err := caller.UpdateClientCertificate([]byte("bad"), []byte("bad")); err == nil {
+		t.Error("Expected an error for invalid certificate data")
+	}
+
+	// gRPC connections without TLS have no certificate to rotate
+	grpcEndpoint := api.Endpoint{FriendlyName: "Insecure gRPC", URL: "localhost:1", Type: api.EndpointTypeGRPC}
+	insecure := &api.APICallerConfig{InsecureSkipVerify: true}
+	grpcCaller, err := api.GetOrCreateAPICaller(grpcEndpoint, insecure)
+	if err != nil {
+		t.Fatalf("Failed to create gRPC caller: %v", err)
+	}
+	if _, err := api.RotateCallerCertificate(grpcEndpoint, insecure, secondCert, secondKey); err == nil {
+		t.Error("Expected an error rotating the certificate of an insecure gRPC caller")
+	}
+	if again, _ := api.GetOrCreateAPICaller(grpcEndpoint, insecure); again != grpcCaller {
+		t.Error("Expected a failed rotation to keep the caller under its config")
+	}
+}
+
+// generateClientCertificate creates a self-signed client certificate and key in PEM form
+func generateClientCertificate(t *testing.T, commonName string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
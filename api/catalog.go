@@ -0,0 +1,342 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trimble-oss/tierceron-core/v2/core"
+)
+
+// EndpointsConfigKey is the ConfigContext.Config key holding the endpoint catalog
+const EndpointsConfigKey = "endpoints"
+
+// ErrEndpointNotFound is returned when calling an endpoint missing from the catalog
+var ErrEndpointNotFound = errors.New("endpoint not found")
+
+var (
+	endpointCatalog      = make(map[string]Endpoint)
+	endpointCatalogMutex sync.RWMutex
+)
+
+// endpointSpec is the declarative form of an Endpoint in plugin configuration
+type endpointSpec struct {
+	FriendlyName        string          `json:"friendlyName"`
+	URL                 string          `json:"url"`
+	Type                EndpointType    `json:"type"`
+	Timeout             catalogDuration `json:"timeout"`
+	WSDLUrl             string          `json:"wsdlUrl"`
+	SOAPVersion         SOAPVersion     `json:"soapVersion"`
+	MethodName          string          `json:"methodName"`
+	MaxRetries          int             `json:"maxRetries"`
+	RetryErrorRange     int             `json:"retryErrorRange"`
+	MaxResponseBytes    int64           `json:"maxResponseBytes"`
+	ExpectedStatusCodes []int           `json:"expectedStatusCodes"`
+	ResponseSchema      json.RawMessage `json:"responseSchema"`
+	InsecureSkipVerify  bool            `json:"insecureSkipVerify"`
+	TLSCert             string          `json:"tlsCert"`
+	TLSKey              string          `json:"tlsKey"`
+	CACert              string          `json:"caCert"`
+}
+
+// catalogDuration accepts a Go duration string ("10s") or a number of seconds
+type catalogDuration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *catalogDuration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case string:
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = catalogDuration(duration)
+	case float64:
+		*d = catalogDuration(time.Duration(v * float64(time.Second)))
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("invalid duration: %v", value)
+	}
+	return nil
+}
+
+// LoadEndpoints parses the endpoint catalog of configContext and registers its
+// endpoints for Lookup, replacing endpoints of the same name.
+func LoadEndpoints(configContext *core.ConfigContext) error {
+	endpoints, err := ParseEndpoints(configContext)
+	if err != nil {
+		return err
+	}
+	endpointCatalogMutex.Lock()
+	defer endpointCatalogMutex.Unlock()
+	maps.Copy(endpointCatalog, endpoints)
+	return nil
+}
+
+// ParseEndpoints builds the named endpoints declared under Config["endpoints"]
+// The catalog maps endpoint names to settings such as url, type, timeout
+// ("10s" or seconds), maxRetries and methodName. Overrides are applied in order
+// from the "env" entry matching ConfigContext.Env, the "region" entry matching
+// ConfigContext.Region, and the "region" entry nested in the env entry.
+// tlsCert, tlsKey and caCert name entries of ConfigContext.ConfigCerts.
+// All invalid endpoints are reported in a single joined error.
+func ParseEndpoints(configContext *core.ConfigContext) (map[string]Endpoint, error) {
+	if configContext == nil || configContext.Config == nil {
+		return nil, errors.New("config context with config is required")
+	}
+	raw, ok := (*configContext.Config)[EndpointsConfigKey]
+	if !ok {
+		return map[string]Endpoint{}, nil
+	}
+	catalog, err := catalogMap(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", EndpointsConfigKey, err)
+	}
+
+	endpoints := make(map[string]Endpoint, len(catalog))
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(catalog)) {
+		settings, err := catalogMap(catalog[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("endpoint '%s': %w", name, err))
+			continue
+		}
+		endpoint, err := buildCatalogEndpoint(name, resolveOverrides(settings, configContext.Env, configContext.Region), configContext.ConfigCerts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("endpoint '%s': %w", name, err))
+			continue
+		}
+		endpoints[name] = endpoint
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return endpoints, nil
+}
+
+// RegisterEndpoint registers endpoint under name for Lookup
+func RegisterEndpoint(name string, endpoint Endpoint) {
+	endpointCatalogMutex.Lock()
+	defer endpointCatalogMutex.Unlock()
+	endpointCatalog[name] = endpoint
+}
+
+// Lookup returns a copy of the registered endpoint name
+// It returns nil if the endpoint is not registered; calling a nil endpoint fails
+// with ErrEndpointNotFound, so api.Lookup("billing").Call(params) is safe.
+func Lookup(name string) *Endpoint {
+	endpointCatalogMutex.RLock()
+	defer endpointCatalogMutex.RUnlock()
+	endpoint, ok := endpointCatalog[name]
+	if !ok {
+		return nil
+	}
+	return &endpoint
+}
+
+// resolveOverrides merges the env and region overrides into settings
+func resolveOverrides(settings map[string]any, env string, region string) map[string]any {
+	resolved := make(map[string]any, len(settings))
+	for key, value := range settings {
+		if key != "env" && key != "region" {
+			resolved[key] = value
+		}
+	}
+
+	var envSettings map[string]any
+	if override := overrideFor(settings["env"], env); override != nil {
+		envSettings = override
+		mergeSettings(resolved, override)
+	}
+	if override := overrideFor(settings["region"], region); override != nil {
+		mergeSettings(resolved, override)
+	}
+	if envSettings != nil {
+		if override := overrideFor(envSettings["region"], region); override != nil {
+			mergeSettings(resolved, override)
+		}
+	}
+	return resolved
+}
+
+// overrideFor returns the override settings for key (case-insensitive), if any
+func overrideFor(overrides any, key string) map[string]any {
+	if key == "" || overrides == nil {
+		return nil
+	}
+	entries, err := catalogMap(overrides)
+	if err != nil {
+		return nil
+	}
+	value, ok := entries[key]
+	if !ok {
+		for name, entry := range entries {
+			if strings.EqualFold(name, key) {
+				value, ok = entry, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	settings, err := catalogMap(value)
+	if err != nil {
+		return nil
+	}
+	return settings
+}
+
+// mergeSettings copies override into settings, skipping nested override sections
+func mergeSettings(settings map[string]any, override map[string]any) {
+	for key, value := range override {
+		if key != "env" && key != "region" {
+			settings[key] = value
+		}
+	}
+}
+
+// buildCatalogEndpoint converts resolved settings into an Endpoint
+func buildCatalogEndpoint(name string, settings map[string]any, configCerts *map[string][]byte) (Endpoint, error) {
+	data, err := json.Marshal(normalizeCatalogValue(settings))
+	if err != nil {
+		return Endpoint{}, err
+	}
+	var spec endpointSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return Endpoint{}, err
+	}
+	if spec.URL == "" {
+		return Endpoint{}, errors.New("url is required")
+	}
+	if spec.FriendlyName == "" {
+		spec.FriendlyName = name
+	}
+	if spec.Type == "" {
+		spec.Type = EndpointTypeREST
+	}
+
+	config := &APICallerConfig{InsecureSkipVerify: spec.InsecureSkipVerify}
+	certs := []struct {
+		key    string
+		target *[]byte
+	}{
+		{spec.TLSCert, &config.TLSCertData},
+		{spec.TLSKey, &config.TLSKeyData},
+		{spec.CACert, &config.CACertData},
+	}
+	for _, cert := range certs {
+		if cert.key == "" {
+			continue
+		}
+		if configCerts == nil || len((*configCerts)[cert.key]) == 0 {
+			return Endpoint{}, fmt.Errorf("certificate '%s' not found in config certs", cert.key)
+		}
+		*cert.target = (*configCerts)[cert.key]
+	}
+	if (len(config.TLSCertData) > 0) != (len(config.TLSKeyData) > 0) {
+		return Endpoint{}, errors.New("tlsCert and tlsKey must be set together")
+	}
+
+	// The response schema may be inline JSON or a JSON document in a string
+	var schemaText string
+	if json.Unmarshal(spec.ResponseSchema, &schemaText) == nil {
+		spec.ResponseSchema = json.RawMessage(schemaText)
+	}
+	if string(spec.ResponseSchema) == "null" {
+		spec.ResponseSchema = nil
+	}
+	var responseSchema *jsonSchema
+	if len(spec.ResponseSchema) > 0 {
+		var err error
+		if responseSchema, err = compileJSONSchema(spec.ResponseSchema); err != nil {
+			return Endpoint{}, err
+		}
+	}
+
+	return Endpoint{
+		FriendlyName:        spec.FriendlyName,
+		URL:                 spec.URL,
+		Type:                spec.Type,
+		Timeout:             time.Duration(spec.Timeout),
+		WSDLUrl:             spec.WSDLUrl,
+		SOAPVersion:         spec.SOAPVersion,
+		MethodName:          spec.MethodName,
+		MaxRetries:          spec.MaxRetries,
+		RetryErrorRange:     spec.RetryErrorRange,
+		MaxResponseBytes:    spec.MaxResponseBytes,
+		ExpectedStatusCodes: spec.ExpectedStatusCodes,
+		ResponseSchema:      spec.ResponseSchema,
+		responseSchema:      responseSchema,
+		Config:              config,
+	}, nil
+}
+
+// catalogMap normalizes JSON and YAML shaped maps (or a JSON document) to map[string]any
+func catalogMap(value any) (map[string]any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, nil
+	case *map[string]any:
+		if v == nil {
+			return nil, errors.New("nil map")
+		}
+		return *v, nil
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, entry := range v {
+			converted[fmt.Sprint(key)] = normalizeCatalogValue(entry)
+		}
+		return converted, nil
+	case string:
+		var converted map[string]any
+		if err := json.Unmarshal([]byte(v), &converted); err != nil {
+			return nil, err
+		}
+		return converted, nil
+	case []byte:
+		var converted map[string]any
+		if err := json.Unmarshal(v, &converted); err != nil {
+			return nil, err
+		}
+		return converted, nil
+	default:
+		return nil, fmt.Errorf("expected a map, got %T", value)
+	}
+}
+
+// normalizeCatalogValue copies nested YAML shaped maps and slices into JSON shaped ones
+func normalizeCatalogValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, entry := range v {
+			converted[key] = normalizeCatalogValue(entry)
+		}
+		return converted
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, entry := range v {
+			converted[fmt.Sprint(key)] = normalizeCatalogValue(entry)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(v))
+		for i, entry := range v {
+			converted[i] = normalizeCatalogValue(entry)
+		}
+		return converted
+	default:
+		return value
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trimble-oss/tierceron-core/v2/core"
+)
+
+// TestParseEndpointsOverrides verifies env and region overrides and cert binding
+func TestParseEndpointsOverrides(t *testing.T) {
+	config := map[string]any{
+		"endpoints": map[string]any{
+			"billing": map[string]any{
+				"url":        "https://billing.example.com",
+				"timeout":    "10s",
+				"maxRetries": 2,
+				"tlsCert":    core.TRCSHHIVEK_CERT,
+				"tlsKey":     core.TRCSHHIVEK_KEY,
+				"env": map[string]any{
+					"QA": map[string]any{
+						"url":     "https://billing.qa.example.com",
+						"timeout": 5,
+						"region": map[any]any{
+							"west": map[string]any{"url": "https://billing-west.qa.example.com"},
+						},
+					},
+				},
+				"region": map[string]any{
+					"west": map[string]any{"maxRetries": 4},
+				},
+			},
+			"users": map[string]any{
+				"url":                 "users.example.com:443",
+				"type":                "grpc",
+				"methodName":          "/users.UserService/GetUser",
+				"expectedStatusCodes": []any{200},
+				"responseSchema":      map[string]any{"type": "object", "required": []any{"id"}},
+			},
+		},
+	}
+	configContext := &core.ConfigContext{
+		Config: &config,
+		Env:    "qa",
+		Region: "west",
+		ConfigCerts: &map[string][]byte{
+			core.TRCSHHIVEK_CERT: []byte("cert"),
+			core.TRCSHHIVEK_KEY:  []byte("key"),
+		},
+	}
+
+	endpoints, err := ParseEndpoints(configContext)
+	if err != nil {
+		t.Fatalf("Failed to parse endpoints: %v", err)
+	}
+
+	billing := endpoints["billing"]
+	if billing.FriendlyName != "billing" || billing.Type != EndpointTypeREST {
+		t.Errorf("Expected defaults for name and type, got %q %q", billing.FriendlyName, billing.Type)
+	}
+	if billing.URL != "https://billing-west.qa.example.com" {
+		t.Errorf("Expected env region URL override, got %s", billing.URL)
+	}
+	if billing.Timeout != 5*time.Second {
+		t.Errorf("Expected env timeout override, got %v", billing.Timeout)
+	}
+	if billing.MaxRetries != 4 {
+		t.Errorf("Expected region retry override, got %d", billing.MaxRetries)
+	}
+	if string(billing.Config.TLSCertData) != "cert" || string(billing.Config.TLSKeyData) != "key" {
+		t.Error("Expected certificates bound from ConfigCerts")
+	}
+
+	users := endpoints["users"]
+	if users.Type != EndpointTypeGRPC || users.MethodName != "/users.UserService/GetUser" || users.Timeout != 0 {
+		t.Errorf("Unexpected gRPC endpoint: %+v", users)
+	}
+	if len(users.ExpectedStatusCodes) != 1 || !strings.Contains(string(users.ResponseSchema), `"required":["id"]`) {
+		t.Errorf("Expected response validation settings, got %v %s", users.ExpectedStatusCodes, users.ResponseSchema)
+	}
+
+	// Without matching overrides the base settings apply
+	configContext.Env = "prod"
+	configContext.Region = ""
+	endpoints, err = ParseEndpoints(configContext)
+	if err != nil {
+		t.Fatalf("Failed to parse endpoints: %v", err)
+	}
+	if endpoints["billing"].URL != "https://billing.example.com" || endpoints["billing"].Timeout != 10*time.Second {
+		t.Errorf("Expected base settings, got %+v", endpoints["billing"])
+	}
+}
+
+// TestParseEndpointsYAMLShaped verifies nested map[any]any sections from a YAML decoder
+func TestParseEndpointsYAMLShaped(t *testing.T) {
+	config := map[string]any{
+		"endpoints": map[any]any{
+			"orders": map[any]any{
+				"url":     "https://orders.example.com",
+				"timeout": 10,
+				"env": map[any]any{
+					"QA": map[any]any{
+						"url": "https://orders.qa.example.com",
+						"region": map[any]any{
+							"east": map[any]any{"maxRetries": 3},
+						},
+					},
+				},
+				"expectedStatusCodes": []any{200, 201},
+				"responseSchema": map[any]any{
+					"type":     "object",
+					"required": []any{"id"},
+					"properties": map[any]any{
+						"id":    map[any]any{"type": "string"},
+						"items": map[any]any{"type": "array", "items": map[any]any{"type": "object"}},
+						// YAML 1.1 decoders turn a "yes" key into a bool
+						true: map[any]any{"type": "boolean"},
+					},
+				},
+			},
+		},
+	}
+	configContext := &core.ConfigContext{Config: &config, Env: "QA", Region: "east"}
+
+	endpoints, err := ParseEndpoints(configContext)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML shaped endpoints: %v", err)
+	}
+	orders := endpoints["orders"]
+	if orders.URL != "https://orders.qa.example.com" || orders.MaxRetries != 3 || orders.Timeout != 10*time.Second {
+		t.Errorf("Expected nested overrides applied, got %+v", orders)
+	}
+	if len(orders.ExpectedStatusCodes) != 2 || !strings.Contains(string(orders.ResponseSchema), `"items":{"items":{"type":"object"},"type":"array"}`) ||
+		!strings.Contains(string(orders.ResponseSchema), `"true":{"type":"boolean"}`) {
+		t.Errorf("Expected nested response schema, got %v %s", orders.ExpectedStatusCodes, orders.ResponseSchema)
+	}
+
+	// The nested sections are also marshalled when no override matches
+	configContext.Env = "prod"
+	if _, err := ParseEndpoints(configContext); err != nil {
+		t.Errorf("Failed to parse YAML shaped endpoints without overrides: %v", err)
+	}
+}
+
+// TestParseEndpointsErrors verifies every invalid endpoint is reported
+func TestParseEndpointsErrors(t *testing.T) {
+	config := map[string]any{
+		"endpoints": map[string]any{
+			"nourl":     map[string]any{"type": "rest"},
+			"nocert":    map[string]any{"url": "https://example.com", "tlsCert": "missing.crt", "tlsKey": "missing.key"},
+			"badtime":   map[string]any{"url": "https://example.com", "timeout": "soon"},
+			"notamap":   "https://example.com",
+			"badschema": map[string]any{"url": "https://example.com", "responseSchema": `{"type": "object"`},
+			"complete":  map[string]any{"url": "https://example.com"},
+		},
+	}
+	_, err := ParseEndpoints(&core.ConfigContext{Config: &config})
+	if err == nil {
+		t.Fatal("Expected an error for invalid endpoints")
+	}
+	for _, name := range []string{"nourl", "nocert", "badtime", "notamap", "badschema"} {
+		if !strings.Contains(err.Error(), "'"+name+"'") {
+			t.Errorf("Expected error for %s, got %v", name, err)
+		}
+	}
+	if strings.Contains(err.Error(), "complete") {
+		t.Errorf("Expected no error for the valid endpoint, got %v", err)
+	}
+}
+
+// TestLookupCall verifies catalog endpoints can be looked up and called by name
+func TestLookupCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"invoice":42}`))
+	}))
+	defer server.Close()
+
+	config := map[string]any{
+		"endpoints": `{"catalog-billing": {"url": "` + server.URL + `", "timeout": "5s"}}`,
+	}
+	if err := LoadEndpoints(&core.ConfigContext{Config: &config}); err != nil {
+		t.Fatalf("Failed to load endpoints: %v", err)
+	}
+	defer RemoveCallerFromCache(*Lookup("catalog-billing"), Lookup("catalog-billing").Config)
+
+	result, err := Lookup("catalog-billing").Call(map[string]any{"method": "GET"})
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if result["body"].(map[string]any)["invoice"] != float64(42) {
+		t.Errorf("Unexpected body: %v", result["body"])
+	}
+
+	if _, err := Lookup("catalog-unknown").Call(nil); !errors.Is(err, ErrEndpointNotFound) {
+		t.Errorf("Expected ErrEndpointNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a call is short-circuited by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is the typed error returned when a circuit breaker rejects a call
+// It matches ErrCircuitOpen with errors.Is
+type CircuitOpenError struct {
+	// FriendlyName is the name of the endpoint whose breaker is open
+	FriendlyName string
+	// RetryAt is the earliest time the breaker will allow a trial call
+	RetryAt time.Time
+}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for endpoint '%s' until %s", e.FriendlyName, e.RetryAt.Format(time.RFC3339))
+}
+
+// Unwrap allows errors.Is(err, ErrCircuitOpen)
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// CircuitState represents the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed allows all calls through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all calls until the cool-down elapses
+	CircuitOpen
+	// CircuitHalfOpen allows a limited number of trial calls through
+	CircuitHalfOpen
+)
+
+// String returns the name of the circuit state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breaker attached to a cached APICaller
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	// If set to 0 or unset, defaults to 5
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before allowing trial calls
+	// If set to 0 or unset, defaults to 30 seconds
+	CoolDown time.Duration
+	// HalfOpenMaxCalls is the number of concurrent trial calls allowed while half-open
+	// If set to 0 or unset, defaults to 1
+	HalfOpenMaxCalls int
+	// SuccessThreshold is the number of successful trial calls needed to close the circuit
+	// If set to 0 or unset, defaults to 1
+	SuccessThreshold int
+	// IsFailure optionally decides whether a call result counts as a failure
+	// If unset, errors count as failures except cancellations, oversized responses, GraphQL errors
+	// and 4xx responses other than 429
+	IsFailure func(response *Response, err error) bool
+}
+
+// CircuitBreakerStats is a snapshot of circuit breaker state for diagnostics
+type CircuitBreakerStats struct {
+	State               CircuitState
+	ConsecutiveFailures int
+	TotalFailures       uint64
+	TotalSuccesses      uint64
+	TotalRejected       uint64
+	OpenedAt            time.Time
+}
+
+// CircuitBreaker tracks call failures and short-circuits calls while a downstream is unhealthy
+type CircuitBreaker struct {
+	name   string
+	config CircuitBreakerConfig
+
+	mu                  sync.Mutex
+	state               CircuitState
+	consecutiveFailures int
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+	totalFailures       uint64
+	totalSuccesses      uint64
+	totalRejected       uint64
+	openedAt            time.Time
+	// generation changes with every state transition; results of calls admitted
+	// in an earlier generation are counted but do not change the state
+	generation uint64
+	now        func() time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker for the named endpoint
+func NewCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+	if config.HalfOpenMaxCalls <= 0 {
+		config.HalfOpenMaxCalls = 1
+	}
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = 1
+	}
+	return &CircuitBreaker{
+		name:   name,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed, returning a *CircuitOpenError if not
+// Every allowed call must be followed by a call to Record with the returned generation
+func (cb *CircuitBreaker) Allow() (uint64, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen {
+		retryAt := cb.openedAt.Add(cb.config.CoolDown)
+		if cb.now().Before(retryAt) {
+			cb.totalRejected++
+			return 0, &CircuitOpenError{FriendlyName: cb.name, RetryAt: retryAt}
+		}
+		// Cool-down elapsed, start allowing trial calls
+		cb.transition(CircuitHalfOpen)
+	}
+
+	if cb.state == CircuitHalfOpen {
+		if cb.halfOpenInFlight >= cb.config.HalfOpenMaxCalls {
+			cb.totalRejected++
+			return 0, &CircuitOpenError{FriendlyName: cb.name, RetryAt: cb.now()}
+		}
+		cb.halfOpenInFlight++
+	}
+
+	return cb.generation, nil
+}
+
+// Record reports the outcome of a call previously allowed by Allow
+// A call admitted before the last state change only updates the totals, so a slow call
+// cannot re-open the circuit, extend the cool-down or release a trial slot it never held.
+func (cb *CircuitBreaker) Record(generation uint64, response *Response, err error) {
+	failed := cb.isFailure(response, err)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if failed {
+		cb.totalFailures++
+	} else {
+		cb.totalSuccesses++
+	}
+	if generation != cb.generation {
+		return
+	}
+
+	if cb.state == CircuitHalfOpen && cb.halfOpenInFlight > 0 {
+		cb.halfOpenInFlight--
+	}
+
+	if failed {
+		cb.consecutiveFailures++
+		if cb.state == CircuitHalfOpen || cb.consecutiveFailures >= cb.config.FailureThreshold {
+			cb.trip()
+		}
+		return
+	}
+
+	cb.consecutiveFailures = 0
+	if cb.state == CircuitHalfOpen {
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.config.SuccessThreshold {
+			cb.transition(CircuitClosed)
+		}
+	}
+}
+
+// transition moves to state and starts a new generation (caller must hold cb.mu)
+func (cb *CircuitBreaker) transition(state CircuitState) {
+	cb.state = state
+	cb.generation++
+	cb.halfOpenInFlight = 0
+	cb.halfOpenSuccesses = 0
+}
+
+// trip opens the circuit (caller must hold cb.mu)
+func (cb *CircuitBreaker) trip() {
+	cb.transition(CircuitOpen)
+	cb.openedAt = cb.now()
+}
+
+// isFailure decides whether a call outcome counts against the breaker
+func (cb *CircuitBreaker) isFailure(response *Response, err error) bool {
+	if cb.config.IsFailure != nil {
+		return cb.config.IsFailure(response, err)
+	}
+	if err == nil || isCanceledError(err) || errors.Is(err, ErrResponseTooLarge) {
+		return false
+	}
+	if response != nil && response.StatusCode >= 400 && response.StatusCode < 500 && response.StatusCode != 429 {
+		// Client errors say nothing about downstream health
+		return false
+	}
+	var graphQLErr *GraphQLError
+	if errors.As(err, &graphQLErr) && graphQLErr.StatusCode < 500 {
+		// GraphQL errors are reported per request, like client errors
+		return false
+	}
+	return true
+}
+
+// State returns the current circuit state
+func (cb *CircuitBreaker) State() CircuitState {
+	return cb.Stats().State
+}
+
+// Stats returns a snapshot of the breaker counters
+func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state := cb.state
+	if state == CircuitOpen && !cb.now().Before(cb.openedAt.Add(cb.config.CoolDown)) {
+		// Report the state the next call would observe
+		state = CircuitHalfOpen
+	}
+
+	return CircuitBreakerStats{
+		State:               state,
+		ConsecutiveFailures: cb.consecutiveFailures,
+		TotalFailures:       cb.totalFailures,
+		TotalSuccesses:      cb.totalSuccesses,
+		TotalRejected:       cb.totalRejected,
+		OpenedAt:            cb.openedAt,
+	}
+}
+
+// Reset closes the circuit and clears the failure counters
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.transition(CircuitClosed)
+	cb.consecutiveFailures = 0
+	cb.openedAt = time.Time{}
+}
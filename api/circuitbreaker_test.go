@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreakerOpensAndRecovers verifies closed -> open -> half-open -> closed transitions
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	cb := NewCircuitBreaker("breaker-unit", CircuitBreakerConfig{
+		FailureThreshold: 2,
+		CoolDown:         time.Minute,
+	})
+	cb.now = func() time.Time { return now }
+
+	failure := &Response{StatusCode: http.StatusInternalServerError}
+	failErr := errors.New("HTTP 500")
+
+	for range 2 {
+		generation, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("Expected call to be allowed while closed: %v", err)
+		}
+		cb.Record(generation, failure, failErr)
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("Expected open circuit, got %s", cb.State())
+	}
+
+	_, err := cb.Allow()
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) {
+		t.Fatalf("Expected *CircuitOpenError, got %v", err)
+	}
+
+	// After the cool-down a single trial call is allowed
+	now = now.Add(time.Minute)
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("Expected half-open circuit, got %s", cb.State())
+	}
+	trial, err := cb.Allow()
+	if err != nil {
+		t.Fatalf("Expected trial call to be allowed: %v", err)
+	}
+	if _, err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected concurrent trial call to be rejected, got %v", err)
+	}
+	cb.Record(trial, &Response{StatusCode: http.StatusOK}, nil)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("Expected closed circuit after successful trial, got %s", cb.State())
+	}
+
+	stats := cb.Stats()
+	if stats.TotalFailures != 2 || stats.TotalSuccesses != 1 || stats.TotalRejected != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestCircuitBreakerIgnoresClientErrors verifies 4xx responses do not trip the breaker
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	cb := NewCircuitBreaker("breaker-4xx", CircuitBreakerConfig{FailureThreshold: 1})
+	generation, _ := cb.Allow()
+	cb.Record(generation, &Response{StatusCode: http.StatusNotFound}, errors.New("HTTP 404"))
+	if cb.State() != CircuitClosed {
+		t.Errorf("Expected closed circuit after 404, got %s", cb.State())
+	}
+}
+
+// TestCircuitBreakerIgnoresStaleResults verifies calls admitted before a state change
+// neither extend the cool-down nor release trial slots
+func TestCircuitBreakerIgnoresStaleResults(t *testing.T) {
+	now := time.Now()
+	cb := NewCircuitBreaker("breaker-stale", CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Minute})
+	cb.now = func() time.Time { return now }
+
+	failure := &Response{StatusCode: http.StatusInternalServerError}
+	failErr := errors.New("HTTP 500")
+	slow, _ := cb.Allow()
+	fast, _ := cb.Allow()
+	cb.Record(fast, failure, failErr)
+	openedAt := cb.Stats().OpenedAt
+
+	// A slow call admitted while closed fails after the circuit opened
+	now = now.Add(30 * time.Second)
+	cb.Record(slow, failure, failErr)
+	if stats := cb.Stats(); !stats.OpenedAt.Equal(openedAt) || stats.TotalFailures != 2 {
+		t.Errorf("Expected the stale failure to be counted without re-opening, got %+v", stats)
+	}
+
+	// A stale result in half-open does not free the trial slot
+	now = now.Add(30 * time.Second)
+	if _, err := cb.Allow(); err != nil {
+		t.Fatalf("Expected trial call to be allowed: %v", err)
+	}
+	cb.Record(slow, &Response{StatusCode: http.StatusOK}, nil)
+	if _, err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the trial slot to stay taken, got %v", err)
+	}
+	if cb.State() != CircuitHalfOpen {
+		t.Errorf("Expected a stale success not to close the circuit, got %s", cb.State())
+	}
+}
+
+// TestEndpointCircuitBreaker verifies cached callers short-circuit calls once open
+func TestEndpointCircuitBreaker(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "breaker-endpoint-test",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+		CircuitBreaker: &CircuitBreakerConfig{
+			FailureThreshold: 2,
+			CoolDown:         time.Minute,
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	for range 2 {
+		endpoint.Call(map[string]any{})
+	}
+
+	_, err := endpoint.Call(map[string]any{})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if attemptNum := atomic.LoadInt32(&attempts); attemptNum != 2 {
+		t.Errorf("Expected 2 attempts to reach the server, got %d", attemptNum)
+	}
+
+	caller, err := GetOrCreateAPICaller(endpoint, nil)
+	if err != nil {
+		t.Fatalf("Failed to get caller: %v", err)
+	}
+	if caller.CircuitBreakerState() != CircuitOpen {
+		t.Errorf("Expected caller breaker to be open, got %s", caller.CircuitBreakerState())
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCallContextCancellation verifies a canceled parent context aborts an in-flight call
+func TestCallContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "context-cancel-test",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      -1,
+		MaxRetries:   3,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	result, err := endpoint.CallContext(ctx, map[string]any{"method": "GET"})
+	if err == nil {
+		t.Fatal("Expected error from canceled call")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected call to stop promptly after cancel, took %v", elapsed)
+	}
+	if canceled, _ := result["canceled"].(bool); !canceled {
+		t.Errorf("Expected canceled=true in result, got: %+v", result)
+	}
+	if _, ok := result["timedOut"]; ok {
+		t.Errorf("Did not expect timedOut in result, got: %+v", result)
+	}
+}
+
+// TestCallContextCancelDuringBackoff verifies cancellation interrupts retry backoff sleeps
+func TestCallContextCancelDuringBackoff(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName:    "context-backoff-test",
+		URL:             server.URL,
+		Type:            EndpointTypeREST,
+		Timeout:         5 * time.Second,
+		MaxRetries:      3,
+		RetryErrorRange: 5,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := endpoint.CallContext(ctx, map[string]any{"method": "GET"})
+	if err == nil {
+		t.Fatal("Expected error after parent deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("Expected backoff to be interrupted, took %v", elapsed)
+	}
+	if attemptNum := atomic.LoadInt32(&attempts); attemptNum != 1 {
+		t.Errorf("Expected 1 attempt before deadline, got: %d", attemptNum)
+	}
+	if timedOut, _ := result["timedOut"].(bool); !timedOut {
+		t.Errorf("Expected timedOut=true in result, got: %+v", result)
+	}
+}
+
+// TestAPICallerCallContextValues verifies the parent context reaches the client
+func TestAPICallerCallContextValues(t *testing.T) {
+	type ctxKey struct{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caller, err := NewAPICaller(Endpoint{
+		FriendlyName: "context-values-test",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create caller: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	options := &CallOptions{Method: "GET"}
+	if _, err := caller.CallContext(ctx, options); err != nil {
+		t.Fatalf("CallContext failed: %v", err)
+	}
+	if options.Context.Value(ctxKey{}) != "value" {
+		t.Error("Expected caller context to carry parent values")
+	}
+}
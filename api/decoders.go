@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeNDJSON yields one value per line of newline-delimited JSON read from r
+// Blank lines are skipped. Iteration stops at the end of r or after the first
+// error, which is yielded with the zero value of T.
+func DecodeNDJSON[T any](r io.Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				var record T
+				if decodeErr := json.Unmarshal(line, &record); decodeErr != nil {
+					yield(record, decodeErr)
+					return
+				}
+				if !yield(record, nil) {
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					var zero T
+					yield(zero, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// SSEEvent is a single server-sent event
+type SSEEvent struct {
+	// ID is the last event ID seen on the stream
+	ID string
+	// Event is the event type ("message" if the server did not name it)
+	Event string
+	// Data is the event payload; multiple data lines are joined with "\n"
+	Data string
+	// Retry is the reconnection delay requested by the server (0 if not sent)
+	Retry time.Duration
+}
+
+// DecodeSSE yields the events of a text/event-stream read from r
+// Comments and events without data are skipped. Iteration stops at the end of
+// r or after the first read error, which is yielded with an empty event.
+func DecodeSSE(r io.Reader) iter.Seq2[SSEEvent, error] {
+	return func(yield func(SSEEvent, error) bool) {
+		reader := bufio.NewReader(r)
+		var lastID string
+		var event SSEEvent
+		var data strings.Builder
+		hasData := false
+		first := true
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				yield(SSEEvent{}, err)
+				return
+			}
+			eof := err != nil
+			if eof && line == "" {
+				// An incomplete final event is discarded
+				return
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			if first {
+				line = strings.TrimPrefix(line, "\ufeff")
+				first = false
+			}
+
+			if line == "" {
+				// A blank line dispatches the event
+				if hasData {
+					event.ID = lastID
+					event.Data = data.String()
+					if event.Event == "" {
+						event.Event = "message"
+					}
+					if !yield(event, nil) {
+						return
+					}
+				}
+				event = SSEEvent{}
+				data.Reset()
+				hasData = false
+			} else if !strings.HasPrefix(line, ":") {
+				field, value, _ := strings.Cut(line, ":")
+				value = strings.TrimPrefix(value, " ")
+				switch field {
+				case "event":
+					event.Event = value
+				case "data":
+					if hasData {
+						data.WriteByte('\n')
+					}
+					data.WriteString(value)
+					hasData = true
+				case "id":
+					if !strings.ContainsRune(value, 0) {
+						lastID = value
+					}
+				case "retry":
+					if ms, convErr := strconv.Atoi(value); convErr == nil && ms >= 0 {
+						event.Retry = time.Duration(ms) * time.Millisecond
+					}
+				}
+			}
+
+			if eof {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// GraphQLRequest is the body of a GraphQL operation
+type GraphQLRequest struct {
+	// Query is the GraphQL document
+	Query string `json:"query"`
+	// OperationName selects the operation when Query contains several
+	OperationName string `json:"operationName,omitempty"`
+	// Variables are the operation variables (a map or a struct marshaled as JSON)
+	Variables any `json:"variables,omitempty"`
+}
+
+// GraphQLResponse is the parsed response of a GraphQL operation
+type GraphQLResponse struct {
+	// Data is the raw "data" member (partial data may accompany errors)
+	Data json.RawMessage `json:"data"`
+	// Errors are the GraphQL errors reported by the server
+	Errors []GraphQLErrorEntry `json:"errors,omitempty"`
+	// Extensions are the optional server extensions
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorEntry is a single GraphQL error
+type GraphQLErrorEntry struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation is a position in the GraphQL document
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is the typed error returned when a GraphQL response contains errors
+type GraphQLError struct {
+	// FriendlyName is the name of the endpoint
+	FriendlyName string
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Errors are the GraphQL errors reported by the server
+	Errors []GraphQLErrorEntry
+}
+
+// Error implements the error interface
+func (e *GraphQLError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, entry := range e.Errors {
+		messages = append(messages, entry.Message)
+	}
+	return fmt.Sprintf("GraphQL error from endpoint '%s': %s", e.FriendlyName, strings.Join(messages, "; "))
+}
+
+// errorMap renders the entry for the Endpoint.Call result map
+func (e GraphQLErrorEntry) errorMap() map[string]any {
+	entry := map[string]any{
+		"message": e.Message,
+	}
+	if len(e.Locations) > 0 {
+		locations := make([]map[string]any, 0, len(e.Locations))
+		for _, location := range e.Locations {
+			locations = append(locations, map[string]any{"line": location.Line, "column": location.Column})
+		}
+		entry["locations"] = locations
+	}
+	if len(e.Path) > 0 {
+		entry["path"] = e.Path
+	}
+	if len(e.Extensions) > 0 {
+		entry["extensions"] = e.Extensions
+	}
+	return entry
+}
+
+// GraphQLClient implements the Client interface for GraphQL APIs
+// Requests are sent as JSON POSTs through a RESTClient, sharing its TLS,
+// authentication and size limit handling.
+type GraphQLClient struct {
+	endpoint Endpoint
+	rest     *RESTClient
+}
+
+// NewGraphQLClient creates a new GraphQL client
+func NewGraphQLClient(endpoint Endpoint, config *APICallerConfig) (*GraphQLClient, error) {
+	rest, err := NewRESTClient(endpoint, config)
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLClient{
+		endpoint: endpoint,
+		rest:     rest,
+	}, nil
+}
+
+// Call executes a GraphQL operation
+// Body must be a GraphQLRequest, *GraphQLRequest or a query string.
+// The response Body is a *GraphQLResponse; GraphQL errors are returned as *GraphQLError.
+func (gc *GraphQLClient) Call(options *CallOptions) (*Response, error) {
+	var request *GraphQLRequest
+	switch v := options.Body.(type) {
+	case *GraphQLRequest:
+		request = v
+	case GraphQLRequest:
+		request = &v
+	case string:
+		request = &GraphQLRequest{Query: v}
+	default:
+		return nil, fmt.Errorf("unsupported GraphQL body type: %T", options.Body)
+	}
+	if request == nil || strings.TrimSpace(request.Query) == "" {
+		return nil, fmt.Errorf("GraphQL query is required")
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GraphQL request: %w", err)
+	}
+
+	headers := make(map[string]string, len(options.Headers)+2)
+	maps.Copy(headers, options.Headers)
+	headers["Content-Type"] = "application/json"
+	if _, ok := headers["Accept"]; !ok {
+		headers["Accept"] = "application/graphql-response+json, application/json"
+	}
+
+	response, err := gc.rest.Call(&CallOptions{
+		Context:    options.Context,
+		Method:     http.MethodPost,
+		Headers:    headers,
+		Body:       payload,
+		Timeout:    options.Timeout,
+		PathParams: options.PathParams,
+		Query:      options.Query,
+		BaseURL:    options.BaseURL,
+		URL:        options.URL,
+	})
+	if response == nil {
+		return response, err
+	}
+
+	raw, ok := response.Body.([]byte)
+	if !ok || len(raw) == 0 {
+		return response, err
+	}
+	var graphQLResponse GraphQLResponse
+	if jsonErr := json.Unmarshal(raw, &graphQLResponse); jsonErr != nil {
+		// Not a GraphQL response (e.g. a proxy error page)
+		if err == nil {
+			err = fmt.Errorf("failed to parse GraphQL response: %w", jsonErr)
+			response.Error = err
+		}
+		return response, err
+	}
+	if err != nil && len(graphQLResponse.Errors) == 0 {
+		// Transport level failure without GraphQL errors
+		return response, err
+	}
+
+	response.Body = &graphQLResponse
+	if len(graphQLResponse.Errors) > 0 {
+		response.Error = &GraphQLError{
+			FriendlyName: gc.endpoint.FriendlyName,
+			StatusCode:   response.StatusCode,
+			Errors:       graphQLResponse.Errors,
+		}
+	} else {
+		response.Error = nil
+	}
+	return response, response.Error
+}
+
+// setClientCertificate swaps the client certificate of the underlying REST client
+func (gc *GraphQLClient) setClientCertificate(cert *tls.Certificate) error {
+	return gc.rest.setClientCertificate(cert)
+}
+
+// Close closes the GraphQL client
+func (gc *GraphQLClient) Close() error {
+	return gc.rest.Close()
+}
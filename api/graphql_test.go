@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestGraphQLServer answers user queries and reports errors for unknown ids
+func newTestGraphQLServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		var request struct {
+			Query         string         `json:"query"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Query == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"Must provide query string."}]}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if request.Variables["id"] == "u-1" && request.OperationName == "GetUser" {
+			w.Write([]byte(`{"data":{"user":{"id":"u-1","name":"Ada"}},"extensions":{"cost":1}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"user":null},"errors":[{"message":"User not found","locations":[{"line":1,"column":9}],"path":["user"],"extensions":{"code":"NOT_FOUND"}}]}`))
+	}))
+}
+
+// TestGraphQLEndpoint verifies data, extensions and errors are separated in the result map
+func TestGraphQLEndpoint(t *testing.T) {
+	server := newTestGraphQLServer(t)
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName:   "Test GraphQL",
+		URL:            server.URL,
+		Type:           EndpointTypeGraphQL,
+		Timeout:        5 * time.Second,
+		CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 1},
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	query := `query GetUser($id: ID!) { user(id: $id) { id name } }`
+	result, err := endpoint.Call(map[string]any{
+		"query":         query,
+		"operationName": "GetUser",
+		"variables":     map[string]any{"id": "u-1"},
+	})
+	if err != nil {
+		t.Fatalf("GraphQL call failed: %v", err)
+	}
+	data, _ := result["body"].(map[string]any)
+	user, _ := data["user"].(map[string]any)
+	if user["name"] != "Ada" {
+		t.Errorf("Expected user Ada, got %v", result["body"])
+	}
+	if extensions, _ := result["extensions"].(map[string]any); extensions["cost"] != float64(1) {
+		t.Errorf("Expected extensions, got %v", result["extensions"])
+	}
+	if _, ok := result["errors"]; ok {
+		t.Errorf("Expected no errors, got %v", result["errors"])
+	}
+
+	result, err = endpoint.Call(map[string]any{
+		"query":         query,
+		"operationName": "GetUser",
+		"variables":     map[string]any{"id": "missing"},
+	})
+	var graphQLErr *GraphQLError
+	if !errors.As(err, &graphQLErr) {
+		t.Fatalf("Expected *GraphQLError, got %T: %v", err, err)
+	}
+	if len(graphQLErr.Errors) != 1 || graphQLErr.Errors[0].Message != "User not found" ||
+		graphQLErr.Errors[0].Extensions["code"] != "NOT_FOUND" || graphQLErr.Errors[0].Locations[0].Column != 9 {
+		t.Errorf("Unexpected GraphQL errors: %+v", graphQLErr.Errors)
+	}
+	errorList, _ := result["errors"].([]map[string]any)
+	if len(errorList) != 1 || errorList[0]["message"] != "User not found" {
+		t.Errorf("Expected errors in result map, got %v", result["errors"])
+	}
+	if data, _ := result["body"].(map[string]any); data == nil || data["user"] != nil {
+		t.Errorf("Expected partial data with null user, got %v", result["body"])
+	}
+
+	// GraphQL errors are not downstream failures
+	caller, _ := GetOrCreateAPICaller(endpoint, &APICallerConfig{})
+	if state := caller.CircuitBreakerState(); state != CircuitClosed {
+		t.Errorf("GraphQL errors should not open the circuit, got %v", state)
+	}
+}
+
+// TestGraphQLValidationError verifies errors on HTTP 400 responses are typed
+func TestGraphQLValidationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/graphql-response+json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":[{"message":"Syntax Error: Unexpected Name \"quer\""}]}`))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{FriendlyName: "Test GraphQL Validation", URL: server.URL, Type: EndpointTypeGraphQL}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	if _, err := endpoint.Call(map[string]any{"body": GraphQLRequest{Query: " "}}); err == nil {
+		t.Error("Expected error for empty query")
+	}
+
+	result, err := endpoint.Call(map[string]any{"query": "quer { user }"})
+	var graphQLErr *GraphQLError
+	if !errors.As(err, &graphQLErr) {
+		t.Fatalf("Expected *GraphQLError, got %T: %v", err, err)
+	}
+	if graphQLErr.StatusCode != http.StatusBadRequest || result["statusCode"] != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d / %v", graphQLErr.StatusCode, result["statusCode"])
+	}
+	if result["body"] != nil {
+		t.Errorf("Expected no data, got %v", result["body"])
+	}
+
+	// Undecodable data is returned as a string with the parse error
+	corrupt := Endpoint{FriendlyName: "Test GraphQL Corrupt Data", URL: server.URL, Type: EndpointTypeGraphQL,
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(endpoint Endpoint, options *CallOptions) (*Response, error) {
+				return &Response{StatusCode: http.StatusOK, Body: &GraphQLResponse{Data: json.RawMessage(`{"user":`)}}, nil
+			}
+		}}}
+	defer RemoveCallerFromCache(corrupt, nil)
+	result, err = corrupt.Call(map[string]any{"query": "{ user }"})
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) || result["body"] != `{"user":` || result["error"] != err.Error() {
+		t.Errorf("Expected a GraphQL data parse error, got %v / %v", err, result)
+	}
+}
+
+// TestCallTypedGraphQL verifies typed variables and data decoding
+func TestCallTypedGraphQL(t *testing.T) {
+	server := newTestGraphQLServer(t)
+	defer server.Close()
+
+	endpoint := &Endpoint{FriendlyName: "Test GraphQL Typed", URL: server.URL, Type: EndpointTypeGraphQL}
+	defer RemoveCallerFromCache(*endpoint, nil)
+
+	type userData struct {
+		User struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	result, err := CallTyped[GraphQLRequest, userData](context.Background(), endpoint, GraphQLRequest{
+		Query:         `query GetUser($id: ID!) { user(id: $id) { id name } }`,
+		OperationName: "GetUser",
+		Variables: struct {
+			ID string `json:"id"`
+		}{ID: "u-1"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if result.Body.User.Name != "Ada" {
+		t.Errorf("Unexpected data: %+v", result.Body)
+	}
+}
@@ -3,69 +3,93 @@ package api
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 // GRPCClient implements the Client interface for gRPC APIs
 type GRPCClient struct {
-	endpoint Endpoint
-	conn     *grpc.ClientConn
-	config   *APICallerConfig
+	endpoint    Endpoint
+	conn        *grpc.ClientConn
+	config      *APICallerConfig
+	certificate *clientCertificate
+	// authClient serves the requests of the Authenticator (nil without one)
+	authClient *http.Client
+	// files holds descriptors from Endpoint.DescriptorSet (nil if not supplied)
+	files *protoregistry.Files
+	// descriptors caches resolved method descriptors by full method name
+	descriptors      map[string]protoreflect.MethodDescriptor
+	descriptorsMutex sync.RWMutex
 }
 
 // NewGRPCClient creates a new gRPC client
 func NewGRPCClient(endpoint Endpoint, config *APICallerConfig) (*GRPCClient, error) {
 	client := &GRPCClient{
-		endpoint: endpoint,
-		config:   config,
+		endpoint:    endpoint,
+		config:      config,
+		descriptors: make(map[string]protoreflect.MethodDescriptor),
 	}
 
-	var opts []grpc.DialOption
+	// Load descriptors for servers without reflection if provided
+	if len(endpoint.DescriptorSet) > 0 {
+		files, err := parseDescriptorSet(endpoint.DescriptorSet)
+		if err != nil {
+			return nil, err
+		}
+		client.files = files
+	}
+
+	// Dialer, proxy, idle and keepalive settings
+	opts, err := grpcDialOptions(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// Configure TLS
+	var tlsConfig *tls.Config
 	if config.InsecureSkipVerify {
 		// Use insecure credentials
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else {
 		// Create TLS configuration
-		tlsConfig := &tls.Config{
-			MinVersion:         tls.VersionTLS12,
-			InsecureSkipVerify: false,
+		var certificate *clientCertificate
+		tlsConfig, certificate, err = buildTLSConfig(config)
+		if err != nil {
+			return nil, err
 		}
+		client.certificate = certificate
 
-		// Load CA certificate if provided
-		if len(config.CACertData) > 0 {
-			caCertPool := x509.NewCertPool()
-			if !caCertPool.AppendCertsFromPEM(config.CACertData) {
-				return nil, fmt.Errorf("failed to parse CA certificate")
-			}
-			tlsConfig.RootCAs = caCertPool
-		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
 
-		// Load client certificate if provided
-		if len(config.TLSCertData) > 0 && len(config.TLSKeyData) > 0 {
-			cert, err := tls.X509KeyPair(config.TLSCertData, config.TLSKeyData)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load client certificate: %w", err)
-			}
-			tlsConfig.Certificates = []tls.Certificate{cert}
+	// Token requests of the Authenticator use HTTPS even on insecure connections
+	if config.Authenticator != nil && tlsConfig == nil {
+		if tlsConfig, _, err = buildTLSConfig(config); err != nil {
+			return nil, err
 		}
-
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	client.authClient, err = newAuthHTTPClient(config, tlsConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	// Set dial timeout
@@ -119,11 +143,26 @@ func (gc *GRPCClient) Call(options *CallOptions) (*Response, error) {
 	// Create dynamic response message
 	response := dynamicpb.NewMessage(methodDesc.Output())
 
-	// Invoke the method
-	err = gc.conn.Invoke(ctx, options.Method, request, response)
+	// Send request headers and credentials as outgoing metadata
+	headers, err := authenticateGRPC(ctx, gc.config.Authenticator, gc.endpoint, gc.authClient, options.Method, options.Headers, func() ([]byte, error) {
+		return proto.Marshal(request)
+	})
+	if err != nil {
+		return &Response{Error: err}, err
+	}
+	ctx = outgoingMetadataContext(ctx, headers)
+
+	// Invoke the method, capturing response headers and trailers
+	var header, trailer metadata.MD
+	err = gc.conn.Invoke(ctx, options.Method, request, response, grpc.Header(&header), grpc.Trailer(&trailer))
 	if err != nil {
+		gc.checkCredentials(err)
+		st := status.Convert(err)
 		return &Response{
-			StatusCode: 500,
+			StatusCode: httpStatusFromGRPCCode(st.Code()),
+			Headers:    mergeMetadata(header, trailer),
+			Trailers:   mergeMetadata(trailer),
+			GRPCStatus: st,
 			Error:      err,
 		}, err
 	}
@@ -138,12 +177,65 @@ func (gc *GRPCClient) Call(options *CallOptions) (*Response, error) {
 	return &Response{
 		StatusCode: 200,
 		Body:       responseMap,
-		Headers:    make(map[string][]string),
+		Headers:    mergeMetadata(header, trailer),
+		Trailers:   mergeMetadata(trailer),
+		GRPCStatus: status.New(codes.OK, ""),
 	}, nil
 }
 
-// getMethodDescriptor retrieves the method descriptor using gRPC reflection
+// checkCredentials discards cached credentials when err reports the server rejected them
+func (gc *GRPCClient) checkCredentials(err error) {
+	if status.Code(err) == codes.Unauthenticated {
+		invalidateCredentials(gc.config.Authenticator)
+	}
+}
+
+// getMethodDescriptor returns the method descriptor for methodName
+// Descriptors are cached per connection and resolved from Endpoint.DescriptorSet
+// when supplied, falling back to gRPC server reflection
 func (gc *GRPCClient) getMethodDescriptor(ctx context.Context, methodName string) (protoreflect.MethodDescriptor, error) {
+	gc.descriptorsMutex.RLock()
+	methodDesc, ok := gc.descriptors[methodName]
+	gc.descriptorsMutex.RUnlock()
+	if ok {
+		return methodDesc, nil
+	}
+
+	serviceName, _, err := parseMethodName(methodName)
+	if err != nil {
+		return nil, err
+	}
+
+	var setErr error
+	if gc.files != nil {
+		methodDesc, setErr = findMethodDescriptor(gc.files, serviceName, methodName)
+	}
+	if methodDesc == nil {
+		methodDesc, err = gc.reflectMethodDescriptor(ctx, serviceName, methodName)
+		if err != nil {
+			if setErr != nil {
+				return nil, fmt.Errorf("%w (descriptor set: %v)", err, setErr)
+			}
+			return nil, err
+		}
+	}
+
+	gc.descriptorsMutex.Lock()
+	gc.descriptors[methodName] = methodDesc
+	gc.descriptorsMutex.Unlock()
+
+	return methodDesc, nil
+}
+
+// reflectMethodDescriptor retrieves the method descriptor using gRPC reflection
+func (gc *GRPCClient) reflectMethodDescriptor(ctx context.Context, serviceName string, methodName string) (protoreflect.MethodDescriptor, error) {
+	// Reflection requests carry the same credentials as calls
+	headers, err := authenticateGRPC(ctx, gc.config.Authenticator, gc.endpoint, gc.authClient, grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfo_FullMethodName, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx = outgoingMetadataContext(ctx, headers)
+
 	// Create reflection client
 	reflectClient := grpc_reflection_v1alpha.NewServerReflectionClient(gc.conn)
 	stream, err := reflectClient.ServerReflectionInfo(ctx)
@@ -152,12 +244,6 @@ func (gc *GRPCClient) getMethodDescriptor(ctx context.Context, methodName string
 	}
 	defer stream.CloseSend()
 
-	// Extract service and method from full method name (e.g., "/package.Service/Method")
-	serviceName, _, err := parseMethodName(methodName)
-	if err != nil {
-		return nil, err
-	}
-
 	// Request file descriptor for service
 	req := &grpc_reflection_v1alpha.ServerReflectionRequest{
 		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_FileContainingSymbol{
@@ -171,6 +257,7 @@ func (gc *GRPCClient) getMethodDescriptor(ctx context.Context, methodName string
 
 	resp, err := stream.Recv()
 	if err != nil {
+		gc.checkCredentials(err)
 		return nil, fmt.Errorf("failed to receive reflection response: %w", err)
 	}
 
@@ -180,33 +267,57 @@ func (gc *GRPCClient) getMethodDescriptor(ctx context.Context, methodName string
 		return nil, fmt.Errorf("unexpected reflection response type")
 	}
 
-	// Parse file descriptor
+	// Parse file descriptor and the dependencies sent along with it
 	if len(fdResp.FileDescriptorResponse.FileDescriptorProto) == 0 {
 		return nil, fmt.Errorf("no file descriptor returned")
 	}
 
-	var fdProto descriptorpb.FileDescriptorProto
-	if err := proto.Unmarshal(fdResp.FileDescriptorResponse.FileDescriptorProto[0], &fdProto); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal file descriptor: %w", err)
+	fdSet := &descriptorpb.FileDescriptorSet{}
+	for _, fdBytes := range fdResp.FileDescriptorResponse.FileDescriptorProto {
+		var fdProto descriptorpb.FileDescriptorProto
+		if err := proto.Unmarshal(fdBytes, &fdProto); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal file descriptor: %w", err)
+		}
+		fdSet.File = append(fdSet.File, &fdProto)
 	}
 
-	fileDesc, err := protodesc.NewFile(&fdProto, nil)
+	files, err := protodesc.NewFiles(fdSet)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file descriptor: %w", err)
 	}
 
-	// Find the method descriptor
-	services := fileDesc.Services()
-	for i := 0; i < services.Len(); i++ {
-		service := services.Get(i)
-		if string(service.FullName()) == serviceName {
-			methods := service.Methods()
-			for j := 0; j < methods.Len(); j++ {
-				method := methods.Get(j)
-				if "/"+string(service.FullName())+"/"+string(method.Name()) == methodName {
-					return method, nil
-				}
-			}
+	return findMethodDescriptor(files, serviceName, methodName)
+}
+
+// parseDescriptorSet builds a file registry from a serialized FileDescriptorSet
+func parseDescriptorSet(data []byte) (*protoregistry.Files, error) {
+	var fdSet descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &fdSet); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(&fdSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptor set: %w", err)
+	}
+	return files, nil
+}
+
+// findMethodDescriptor looks up a method descriptor in a file registry
+func findMethodDescriptor(files *protoregistry.Files, serviceName string, methodName string) (protoreflect.MethodDescriptor, error) {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", serviceName, err)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+
+	methods := service.Methods()
+	for j := 0; j < methods.Len(); j++ {
+		method := methods.Get(j)
+		if "/"+string(service.FullName())+"/"+string(method.Name()) == methodName {
+			return method, nil
 		}
 	}
 
@@ -238,8 +349,20 @@ func parseMethodName(fullMethod string) (string, string, error) {
 	return fullMethod[:lastSlash], fullMethod[lastSlash+1:], nil
 }
 
-// mapToProtoMessage converts map[string]any to a protobuf message
+// mapToProtoMessage converts map[string]any, a struct or a proto.Message to a protobuf message
 func (gc *GRPCClient) mapToProtoMessage(data any, msg protoreflect.Message) error {
+	// Generated messages are converted through the wire format
+	if pm, ok := data.(proto.Message); ok {
+		wire, err := proto.Marshal(pm)
+		if err != nil {
+			return fmt.Errorf("failed to marshal protobuf message: %w", err)
+		}
+		if err := proto.Unmarshal(wire, msg.Interface()); err != nil {
+			return fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+		}
+		return nil
+	}
+
 	// Convert map to JSON, then use protojson to unmarshal into message
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -278,6 +401,25 @@ func (gc *GRPCClient) protoMessageToMap(msg protoreflect.Message) (map[string]an
 	return result, nil
 }
 
+// setClientCertificate swaps the client certificate used when the connection is re-established
+// Connections without TLS (InsecureSkipVerify) present no certificate to rotate.
+func (gc *GRPCClient) setClientCertificate(cert *tls.Certificate) error {
+	if gc.certificate == nil {
+		return errors.New("client certificate rotation requires a TLS connection")
+	}
+	gc.certificate.set(cert)
+	return nil
+}
+
+// ClearDescriptorCache drops cached method descriptors so they are resolved again
+// Useful after the server has been redeployed with changed service definitions
+func (gc *GRPCClient) ClearDescriptorCache() {
+	gc.descriptorsMutex.Lock()
+	defer gc.descriptorsMutex.Unlock()
+
+	gc.descriptors = make(map[string]protoreflect.MethodDescriptor)
+}
+
 // GetConnection returns the underlying gRPC connection for custom service clients
 func (gc *GRPCClient) GetConnection() *grpc.ClientConn {
 	return gc.conn
@@ -285,6 +427,9 @@ func (gc *GRPCClient) GetConnection() *grpc.ClientConn {
 
 // Close closes the gRPC connection
 func (gc *GRPCClient) Close() error {
+	if gc.authClient != nil {
+		gc.authClient.CloseIdleConnections()
+	}
 	if gc.conn != nil {
 		return gc.conn.Close()
 	}
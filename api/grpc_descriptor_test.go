@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestGRPCDescriptorSetWithoutReflection verifies calls work against servers with reflection disabled
+func TestGRPCDescriptorSetWithoutReflection(t *testing.T) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	if err := registerTestUserService(server); err != nil {
+		t.Fatalf("Failed to register service: %v", err)
+	}
+	// Reflection intentionally not registered
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			log.Printf("Test gRPC server stopped: %v", err)
+		}
+	}()
+	defer server.GracefulStop()
+
+	fd, err := protoregistry.GlobalFiles.FindFileByPath("user.proto")
+	if err != nil {
+		t.Fatalf("Failed to find user.proto: %v", err)
+	}
+	descriptorSet, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(fd)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	endpoint := Endpoint{
+		FriendlyName:  "Test User Service No Reflection",
+		URL:           fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port),
+		Type:          EndpointTypeGRPC,
+		MethodName:    "/user.UserService/GetUser",
+		Timeout:       10 * time.Second,
+		DescriptorSet: descriptorSet,
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	for range 2 {
+		result, err := endpoint.Call(map[string]any{
+			"body": map[string]any{"user_id": "noreflect"},
+		})
+		if err != nil {
+			t.Fatalf("gRPC call failed: %v", err)
+		}
+		body, _ := result["body"].(map[string]any)
+		if body["user_id"] != "noreflect" {
+			t.Errorf("Expected user_id 'noreflect', got %v", body["user_id"])
+		}
+	}
+
+	caller, err := GetOrCreateAPICaller(endpoint, endpoint.Config)
+	if err != nil {
+		t.Fatalf("Failed to get caller: %v", err)
+	}
+	grpcClient := caller.client.(*GRPCClient)
+	if len(grpcClient.descriptors) != 1 {
+		t.Errorf("Expected 1 cached descriptor, got %d", len(grpcClient.descriptors))
+	}
+}
+
+// TestGRPCInvalidDescriptorSet verifies malformed descriptor sets are rejected up front
+func TestGRPCInvalidDescriptorSet(t *testing.T) {
+	_, err := NewGRPCClient(Endpoint{
+		FriendlyName:  "Invalid Descriptor Set",
+		URL:           "localhost:1",
+		Type:          EndpointTypeGRPC,
+		DescriptorSet: []byte("not a descriptor set"),
+	}, &APICallerConfig{InsecureSkipVerify: true})
+	if err == nil {
+		t.Error("Expected error for invalid descriptor set")
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // registers google.rpc error detail types
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// httpStatusFromGRPCCode maps a gRPC status code to the closest HTTP status code
+// so callers can branch on Response.StatusCode for all endpoint types
+func httpStatusFromGRPCCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		// Unknown, Internal, DataLoss
+		return http.StatusInternalServerError
+	}
+}
+
+// grpcStatusDetails decodes the google.rpc.Status details of st into maps
+// Known detail types are rendered with protojson (including "@type");
+// unknown types are returned with their type URL and raw bytes
+func grpcStatusDetails(st *status.Status) []any {
+	anyDetails := st.Proto().GetDetails()
+	if len(anyDetails) == 0 {
+		return nil
+	}
+
+	details := make([]any, 0, len(anyDetails))
+	for _, detail := range anyDetails {
+		var decoded map[string]any
+		if jsonData, err := protojson.Marshal(detail); err == nil && json.Unmarshal(jsonData, &decoded) == nil {
+			details = append(details, decoded)
+			continue
+		}
+		details = append(details, map[string]any{
+			"@type": detail.GetTypeUrl(),
+			"value": detail.GetValue(),
+		})
+	}
+	return details
+}
+
+// outgoingMetadataContext adds headers to the outgoing gRPC metadata of ctx
+// Header names are lowercased as required by gRPC
+func outgoingMetadataContext(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	md := metadata.New(headers)
+	if existing, ok := metadata.FromOutgoingContext(ctx); ok {
+		md = metadata.Join(existing, md)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// mergeMetadata combines gRPC header and trailer metadata into a header map
+func mergeMetadata(mds ...metadata.MD) map[string][]string {
+	headers := make(map[string][]string)
+	for _, md := range mds {
+		for key, values := range md {
+			headers[key] = append(headers[key], values...)
+		}
+	}
+	return headers
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// TestGRPCMetadataAndTrailers verifies headers are sent as metadata and response metadata is surfaced
+func TestGRPCMetadataAndTrailers(t *testing.T) {
+	server, port, err := startTestMetaServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Meta Service",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/meta.MetaService/Check",
+		Timeout:      10 * time.Second,
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	result, err := endpoint.Call(map[string]any{
+		"headers": map[string]string{"X-Request-Id": "req-42"},
+		"body":    map[string]any{"name": "ok"},
+	})
+	if err != nil {
+		t.Fatalf("gRPC call failed: %v", err)
+	}
+
+	headers, _ := result["headers"].(map[string][]string)
+	if len(headers["x-echo-request-id"]) == 0 || headers["x-echo-request-id"][0] != "req-42" {
+		t.Errorf("Expected echoed request id header, got %v", headers)
+	}
+	trailers, _ := result["trailers"].(map[string][]string)
+	if len(trailers["x-trailer"]) == 0 || trailers["x-trailer"][0] != "done" {
+		t.Errorf("Expected trailer x-trailer=done, got %v", trailers)
+	}
+	if len(headers["x-trailer"]) == 0 {
+		t.Errorf("Expected trailers to be merged into headers, got %v", headers)
+	}
+	if result["grpcStatus"] != "OK" {
+		t.Errorf("Expected grpcStatus OK, got %v", result["grpcStatus"])
+	}
+}
+
+// TestGRPCStatusDetails verifies status codes and error details are decoded into the result map
+func TestGRPCStatusDetails(t *testing.T) {
+	server, port, err := startTestMetaServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Meta Service Errors",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/meta.MetaService/Check",
+		Timeout:      10 * time.Second,
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	result, err := endpoint.Call(map[string]any{
+		"body": map[string]any{"name": "missing"},
+	})
+	if err == nil {
+		t.Fatal("Expected gRPC error")
+	}
+
+	if statusCode, _ := result["statusCode"].(int); statusCode != 404 {
+		t.Errorf("Expected status code 404, got %v", result["statusCode"])
+	}
+	if code, _ := result["grpcCode"].(int); codes.Code(code) != codes.NotFound {
+		t.Errorf("Expected grpcCode NotFound, got %v", result["grpcCode"])
+	}
+	if result["grpcMessage"] != "widget missing" {
+		t.Errorf("Expected grpcMessage 'widget missing', got %v", result["grpcMessage"])
+	}
+
+	details, _ := result["errorDetails"].([]any)
+	if len(details) != 1 {
+		t.Fatalf("Expected 1 error detail, got %v", result["errorDetails"])
+	}
+	detail, _ := details[0].(map[string]any)
+	if detail["reason"] != "WIDGET_NOT_FOUND" || detail["@type"] != "type.googleapis.com/google.rpc.ErrorInfo" {
+		t.Errorf("Unexpected error detail: %v", detail)
+	}
+}
+
+// startTestMetaServer starts a gRPC server exercising metadata and status details
+func startTestMetaServer() (*grpc.Server, string, error) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen: %w", err)
+	}
+
+	port := fmt.Sprintf("%d", lis.Addr().(*net.TCPAddr).Port)
+
+	server := grpc.NewServer()
+	if err := registerTestMetaService(server); err != nil {
+		return nil, "", fmt.Errorf("failed to register service: %w", err)
+	}
+	reflection.Register(server)
+
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			log.Printf("Test gRPC server stopped: %v", err)
+		}
+	}()
+
+	return server, port, nil
+}
+
+// registerTestMetaService registers meta.MetaService with a unary Check method
+func registerTestMetaService(server *grpc.Server) error {
+	fileDesc := &descriptorpb.FileDescriptorProto{
+		Name:    new("meta.proto"),
+		Package: new("meta"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: new("CheckRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:   new("name"),
+						Number: proto.Int32(1),
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: new("MetaService"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       new("Check"),
+						InputType:  new(".meta.CheckRequest"),
+						OutputType: new(".meta.CheckRequest"),
+					},
+				},
+			},
+		},
+	}
+
+	// Reuse the registered file when another test already started the service
+	fd, err := protoregistry.GlobalFiles.FindFileByPath(fileDesc.GetName())
+	if err != nil {
+		fd, err = protodesc.NewFile(fileDesc, protoregistry.GlobalFiles)
+		if err != nil {
+			return fmt.Errorf("failed to create file descriptor: %w", err)
+		}
+		if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+			return fmt.Errorf("failed to register file descriptor: %w", err)
+		}
+	}
+
+	reqDesc := fd.Messages().ByName("CheckRequest")
+	nameField := reqDesc.Fields().ByName("name")
+
+	handler := func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		reqMsg := dynamicpb.NewMessage(reqDesc)
+		if err := dec(reqMsg); err != nil {
+			return nil, err
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs("x-echo-request-id", firstMetadataValue(md, "x-request-id")))
+		grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", "done"))
+
+		if reqMsg.Get(nameField).String() == "missing" {
+			st, err := status.New(codes.NotFound, "widget missing").WithDetails(&errdetails.ErrorInfo{
+				Reason: "WIDGET_NOT_FOUND",
+				Domain: "example.com",
+			})
+			if err != nil {
+				return nil, err
+			}
+			return nil, st.Err()
+		}
+
+		respMsg := dynamicpb.NewMessage(reqDesc)
+		respMsg.Set(nameField, protoreflect.ValueOfString(reqMsg.Get(nameField).String()))
+		return respMsg, nil
+	}
+
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "meta.MetaService",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Check", Handler: handler},
+		},
+		Metadata: fileDesc.GetName(),
+	}, struct{}{})
+	return nil
+}
+
+// firstMetadataValue returns the first value for key or an empty string
+func firstMetadataValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCStream is a dynamic server-, client- or bidirectional-streaming gRPC call
+// Messages are sent and received as map[string]any, converted with the method descriptors
+type GRPCStream struct {
+	gc         *GRPCClient
+	methodDesc protoreflect.MethodDescriptor
+	stream     grpc.ClientStream
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	// onDone is called once with the outcome when the stream ends
+	onDone func(err error)
+	done   sync.Once
+}
+
+// NewStream opens a streaming call for the full method name (e.g., "/package.Service/Method")
+// The stream lives until ctx is done, Close is called or the server ends it
+func (gc *GRPCClient) NewStream(ctx context.Context, method string) (*GRPCStream, error) {
+	if method == "" {
+		return nil, fmt.Errorf("method is required for gRPC calls")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	methodDesc, err := gc.getMethodDescriptor(ctx, method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get method descriptor: %w", err)
+	}
+	if !methodDesc.IsStreamingClient() && !methodDesc.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is unary, use Call instead", method)
+	}
+
+	headers, err := authenticateGRPC(ctx, gc.config.Authenticator, gc.endpoint, gc.authClient, method, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx = outgoingMetadataContext(ctx, headers)
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	streamDesc := &grpc.StreamDesc{
+		StreamName:    string(methodDesc.Name()),
+		ServerStreams: methodDesc.IsStreamingServer(),
+		ClientStreams: methodDesc.IsStreamingClient(),
+	}
+	stream, err := gc.conn.NewStream(streamCtx, streamDesc, method)
+	if err != nil {
+		gc.checkCredentials(err)
+		cancel()
+		return nil, fmt.Errorf("failed to open gRPC stream: %w", err)
+	}
+
+	return &GRPCStream{
+		gc:         gc,
+		methodDesc: methodDesc,
+		stream:     stream,
+		ctx:        streamCtx,
+		cancel:     cancel,
+	}, nil
+}
+
+// watch calls onDone once the stream ends: when Recv fails or returns io.EOF,
+// or when its context is done
+func (s *GRPCStream) watch(onDone func(err error)) {
+	s.onDone = onDone
+	context.AfterFunc(s.ctx, func() {
+		s.finish(s.ctx.Err())
+	})
+}
+
+// finish reports the outcome of the stream to onDone, once
+func (s *GRPCStream) finish(err error) {
+	s.done.Do(func() {
+		if s.onDone != nil {
+			s.onDone(err)
+		}
+	})
+}
+
+// IsClientStreaming reports whether the client may send more than one message
+func (s *GRPCStream) IsClientStreaming() bool {
+	return s.methodDesc.IsStreamingClient()
+}
+
+// IsServerStreaming reports whether the server may send more than one message
+func (s *GRPCStream) IsServerStreaming() bool {
+	return s.methodDesc.IsStreamingServer()
+}
+
+// Send converts body to the method input message and sends it
+func (s *GRPCStream) Send(body any) error {
+	request := dynamicpb.NewMessage(s.methodDesc.Input())
+	if err := s.gc.mapToProtoMessage(body, request); err != nil {
+		return fmt.Errorf("failed to convert request to protobuf: %w", err)
+	}
+	return s.stream.SendMsg(request)
+}
+
+// CloseSend signals that no more messages will be sent
+func (s *GRPCStream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// Recv receives the next message from the server
+// Returns io.EOF once the server has finished the stream
+func (s *GRPCStream) Recv() (map[string]any, error) {
+	response := dynamicpb.NewMessage(s.methodDesc.Output())
+	if err := s.stream.RecvMsg(response); err != nil {
+		if errors.Is(err, io.EOF) {
+			s.finish(nil)
+		} else {
+			s.gc.checkCredentials(err)
+			s.finish(err)
+		}
+		return nil, err
+	}
+
+	responseMap, err := s.gc.protoMessageToMap(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert response to map: %w", err)
+	}
+	return responseMap, nil
+}
+
+// Messages returns an iterator over received messages
+// Iteration stops at the end of the stream; any other error is yielded once as the final element
+func (s *GRPCStream) Messages() iter.Seq2[map[string]any, error] {
+	return func(yield func(map[string]any, error) bool) {
+		for {
+			msg, err := s.Recv()
+			if errors.Is(err, io.EOF) {
+				s.Close()
+				return
+			}
+			if err != nil {
+				s.Close()
+				yield(nil, err)
+				return
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Header returns the response header metadata, blocking until it is available
+func (s *GRPCStream) Header() (map[string][]string, error) {
+	header, err := s.stream.Header()
+	if err != nil {
+		return nil, err
+	}
+	return mergeMetadata(header), nil
+}
+
+// Trailer returns the response trailer metadata
+// Only available once Recv has returned io.EOF or an error
+func (s *GRPCStream) Trailer() map[string][]string {
+	return mergeMetadata(s.stream.Trailer())
+}
+
+// Close cancels the stream and releases its resources
+func (s *GRPCStream) Close() {
+	s.cancel()
+	s.finish(context.Canceled)
+}
+
+// Stream opens a streaming gRPC call to this endpoint using ctx for its lifetime
+// Endpoint.Timeout is not applied to streams; cancel ctx or call Close to end them
+// Parameters:
+//   - "method" (string): Full method name; defaults to e.MethodName
+//   - "headers" (map[string]string): Sent as outgoing metadata
+//   - "body" (any): Initial request message(s); a []map[string]any sends one message per element
+//
+// For server-streaming methods the body is sent and the send side is closed.
+// For client- and bidirectional-streaming methods the send side is left open.
+//
+// Streams are opened through the caller's rate limiter, circuit breaker and middleware,
+// but are neither retried nor recorded. A stream holds its MaxInFlight slot until it
+// ends, and its final status is reported to the circuit breaker.
+func (e *Endpoint) Stream(ctx context.Context, params map[string]any) (*GRPCStream, error) {
+	if e == nil {
+		return nil, ErrEndpointNotFound
+	}
+	if e.Type != EndpointTypeGRPC {
+		return nil, fmt.Errorf("streaming is only supported for gRPC endpoints, got: %s", e.Type)
+	}
+	if params == nil {
+		params = make(map[string]any)
+	}
+
+	config := e.Config
+	if config == nil {
+		config = &APICallerConfig{}
+	}
+
+	caller, release, err := acquireAPICaller(*e, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API caller: %w", err)
+	}
+	// The open stream holds its own use of the caller
+	defer release()
+
+	method, _ := params["method"].(string)
+	if method == "" {
+		method = e.MethodName
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	options := &CallOptions{Method: method, Middleware: e.Middleware}
+	if headers, ok := params["headers"].(map[string]string); ok {
+		options.Headers = headers
+	}
+
+	stream, err := caller.openStream(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	body, hasBody := params["body"]
+	if (!hasBody || body == nil) && !stream.IsClientStreaming() {
+		// Server-streaming methods always take exactly one request message
+		body, hasBody = map[string]any{}, true
+	}
+	if hasBody && body != nil {
+		messages := []any{body}
+		if list, ok := body.([]map[string]any); ok {
+			messages = messages[:0]
+			for _, msg := range list {
+				messages = append(messages, msg)
+			}
+		}
+		for _, msg := range messages {
+			if err := stream.Send(msg); err != nil {
+				if errors.Is(err, io.EOF) {
+					// The server ended the stream; Recv reports its status
+					if _, recvErr := stream.Recv(); recvErr != nil && !errors.Is(recvErr, io.EOF) {
+						err = recvErr
+					}
+				}
+				stream.Close()
+				return nil, fmt.Errorf("failed to send stream message: %w", err)
+			}
+		}
+	}
+
+	if !stream.IsClientStreaming() {
+		if err := stream.CloseSend(); err != nil {
+			stream.Close()
+			return nil, fmt.Errorf("failed to close stream send side: %w", err)
+		}
+	}
+
+	return stream, nil
+}
+
+// openStream opens a gRPC stream through the rate limiter, circuit breaker and middleware
+// Middleware sees a Response whose Body is the *GRPCStream. The concurrency slot is
+// released, the breaker outcome recorded and the caller use ended when the stream ends.
+func (ac *APICaller) openStream(ctx context.Context, options *CallOptions) (*GRPCStream, error) {
+	grpcClient, ok := ac.client.(*GRPCClient)
+	if !ok {
+		return nil, errors.New("cached caller does not use a gRPC client")
+	}
+	options.Context = ctx
+	ac.touch()
+
+	done, ok := ac.acquire()
+	if !ok {
+		return nil, errCallerClosed
+	}
+	release := func() {}
+	if ac.limiter != nil {
+		var err error
+		if release, err = ac.limiter.acquire(ctx); err != nil {
+			done()
+			return nil, err
+		}
+	}
+	var generation uint64
+	if ac.breaker != nil {
+		var err error
+		if generation, err = ac.breaker.Allow(); err != nil {
+			release()
+			done()
+			return nil, err
+		}
+	}
+	finish := func(response *Response, err error) {
+		if ac.breaker != nil {
+			ac.breaker.Record(generation, response, err)
+		}
+		release()
+		done()
+	}
+
+	var stream *GRPCStream
+	call := chainMiddleware(func(endpoint Endpoint, options *CallOptions) (*Response, error) {
+		opened, err := grpcClient.NewStream(outgoingMetadataContext(options.Context, options.Headers), options.Method)
+		if err != nil {
+			return nil, err
+		}
+		stream = opened
+		return &Response{Body: opened}, nil
+	}, options.Middleware)
+
+	response, err := call(ac.endpoint, options)
+	if err == nil && stream == nil {
+		err = errors.New("middleware did not open the gRPC stream")
+	}
+	if err != nil {
+		if stream != nil {
+			stream.Close()
+		}
+		finish(response, err)
+		return nil, err
+	}
+	stream.watch(func(err error) {
+		finish(nil, err)
+	})
+	return stream, nil
+}
@@ -0,0 +1,386 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// TestGRPCServerStreaming verifies server-streaming calls yield every message
+func TestGRPCServerStreaming(t *testing.T) {
+	server, port, err := startTestStreamServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Counter Service",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/counter.CounterService/Count",
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := endpoint.Stream(ctx, map[string]any{
+		"body": map[string]any{"value": 3},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+
+	var values []float64
+	for msg, err := range stream.Messages() {
+		if err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		value, _ := msg["value"].(float64)
+		values = append(values, value)
+	}
+
+	if len(values) != 3 || values[0] != 1 || values[2] != 3 {
+		t.Errorf("Expected values [1 2 3], got %v", values)
+	}
+}
+
+// TestGRPCBidiStreaming verifies bidirectional calls send and receive messages
+func TestGRPCBidiStreaming(t *testing.T) {
+	server, port, err := startTestStreamServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Counter Echo",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/counter.CounterService/Echo",
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := endpoint.Stream(ctx, map[string]any{
+		"body": []map[string]any{{"value": 7}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer stream.Close()
+
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if value, _ := msg["value"].(float64); value != 7 {
+		t.Errorf("Expected echoed value 7, got %v", msg["value"])
+	}
+
+	if err := stream.Send(map[string]any{"value": 8}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend failed: %v", err)
+	}
+	msg, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if value, _ := msg["value"].(float64); value != 8 {
+		t.Errorf("Expected echoed value 8, got %v", msg["value"])
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+// TestGRPCStreamGuards verifies streams go through middleware, the rate limiter and the circuit breaker
+func TestGRPCStreamGuards(t *testing.T) {
+	server, port, err := startTestStreamServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	var opened int
+	endpoint := Endpoint{
+		FriendlyName:   "Test Counter Service Guards",
+		URL:            fmt.Sprintf("localhost:%s", port),
+		Type:           EndpointTypeGRPC,
+		MethodName:     "/counter.CounterService/Count",
+		Config:         &APICallerConfig{InsecureSkipVerify: true},
+		RateLimit:      &RateLimitConfig{MaxInFlight: 1, FailFast: true},
+		CircuitBreaker: &CircuitBreakerConfig{},
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(endpoint Endpoint, options *CallOptions) (*Response, error) {
+				response, err := next(endpoint, options)
+				if err == nil {
+					if _, ok := response.Body.(*GRPCStream); ok {
+						opened++
+					}
+				}
+				return response, err
+			}
+		}},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	params := map[string]any{"body": map[string]any{"value": 2}}
+
+	stream, err := endpoint.Stream(ctx, params)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if _, err := endpoint.Stream(ctx, params); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected a second stream to exceed MaxInFlight, got %v", err)
+	}
+	for _, err := range stream.Messages() {
+		if err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+	}
+
+	// The slot is released once the stream ends
+	again, err := endpoint.Stream(ctx, params)
+	if err != nil {
+		t.Fatalf("Expected a stream after the first ended: %v", err)
+	}
+	again.Close()
+	if opened != 2 {
+		t.Errorf("Expected middleware to see 2 opened streams, got %d", opened)
+	}
+	caller, _ := GetOrCreateAPICaller(endpoint, endpoint.Config)
+	if stats := caller.breaker.Stats(); stats.TotalSuccesses != 2 || stats.TotalFailures != 0 {
+		t.Errorf("Expected both streams recorded as successes, got %+v", stats)
+	}
+
+	var missing *Endpoint
+	if _, err := missing.Stream(ctx, params); !errors.Is(err, ErrEndpointNotFound) {
+		t.Errorf("Expected ErrEndpointNotFound for a nil endpoint, got %v", err)
+	}
+}
+
+// TestGRPCStreamKeepsEvictedCaller verifies an evicted caller is closed only after its open stream ends
+func TestGRPCStreamKeepsEvictedCaller(t *testing.T) {
+	server, port, err := startTestStreamServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Counter Evicted",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/counter.CounterService/Echo",
+		Config:       &APICallerConfig{InsecureSkipVerify: true},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := endpoint.Stream(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	caller, _ := GetOrCreateAPICaller(endpoint, endpoint.Config)
+	RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	// The stream keeps working after eviction
+	if err := stream.Send(map[string]any{"value": 4}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if msg, err := stream.Recv(); err != nil || msg["value"] != 4.0 {
+		t.Fatalf("Expected the evicted caller to stay open, got %v %v", msg, err)
+	}
+	if _, err := caller.CallContext(ctx, &CallOptions{Method: endpoint.MethodName}); errors.Is(err, errCallerClosed) {
+		t.Fatal("Expected the caller to stay open while the stream is in use")
+	}
+
+	stream.Close()
+	if _, err := caller.CallContext(ctx, &CallOptions{Method: endpoint.MethodName}); !errors.Is(err, errCallerClosed) {
+		t.Errorf("Expected the evicted caller to be closed after the stream ended, got %v", err)
+	}
+
+	// The endpoint gets a new caller
+	again, err := endpoint.Stream(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to open stream with a new caller: %v", err)
+	}
+	again.Close()
+}
+
+// TestGRPCStreamRejectsUnary verifies unary methods are not opened as streams
+func TestGRPCStreamRejectsUnary(t *testing.T) {
+	server, port, err := startTestGRPCServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test User Service Stream",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/user.UserService/GetUser",
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+		},
+	}
+
+	if _, err := endpoint.Stream(context.Background(), nil); err == nil {
+		t.Error("Expected error when streaming a unary method")
+	}
+}
+
+// startTestStreamServer starts a gRPC server with a streaming CounterService
+func startTestStreamServer() (*grpc.Server, string, error) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen: %w", err)
+	}
+
+	port := fmt.Sprintf("%d", lis.Addr().(*net.TCPAddr).Port)
+
+	server := grpc.NewServer()
+	if err := registerTestCounterService(server); err != nil {
+		return nil, "", fmt.Errorf("failed to register service: %w", err)
+	}
+	reflection.Register(server)
+
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			log.Printf("Test gRPC server stopped: %v", err)
+		}
+	}()
+
+	return server, port, nil
+}
+
+// testCounterFileDescriptor describes counter.CounterService with streaming methods
+func testCounterFileDescriptor() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    new("counter.proto"),
+		Package: new("counter"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: new("Number"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:   new("value"),
+						Number: proto.Int32(1),
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+						Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: new("CounterService"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:            new("Count"),
+						InputType:       new(".counter.Number"),
+						OutputType:      new(".counter.Number"),
+						ServerStreaming: proto.Bool(true),
+					},
+					{
+						Name:            new("Echo"),
+						InputType:       new(".counter.Number"),
+						OutputType:      new(".counter.Number"),
+						ClientStreaming: proto.Bool(true),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+}
+
+// registerTestCounterService registers the streaming CounterService
+func registerTestCounterService(server *grpc.Server) error {
+	fileDesc := testCounterFileDescriptor()
+
+	// Reuse the registered file when another test already started the service
+	fd, err := protoregistry.GlobalFiles.FindFileByPath(fileDesc.GetName())
+	if err != nil {
+		fd, err = protodesc.NewFile(fileDesc, protoregistry.GlobalFiles)
+		if err != nil {
+			return fmt.Errorf("failed to create file descriptor: %w", err)
+		}
+		if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+			return fmt.Errorf("failed to register file descriptor: %w", err)
+		}
+	}
+
+	numberDesc := fd.Messages().ByName("Number")
+	valueField := numberDesc.Fields().ByName("value")
+
+	count := func(srv any, stream grpc.ServerStream) error {
+		req := dynamicpb.NewMessage(numberDesc)
+		if err := stream.RecvMsg(req); err != nil {
+			return err
+		}
+		for i := int32(1); i <= int32(req.Get(valueField).Int()); i++ {
+			resp := dynamicpb.NewMessage(numberDesc)
+			resp.Set(valueField, protoreflect.ValueOfInt32(i))
+			if err := stream.SendMsg(resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	echo := func(srv any, stream grpc.ServerStream) error {
+		for {
+			req := dynamicpb.NewMessage(numberDesc)
+			if err := stream.RecvMsg(req); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+			if err := stream.SendMsg(req); err != nil {
+				return err
+			}
+		}
+	}
+
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "counter.CounterService",
+		HandlerType: (*any)(nil),
+		Streams: []grpc.StreamDesc{
+			{StreamName: "Count", Handler: count, ServerStreams: true},
+			{StreamName: "Echo", Handler: echo, ServerStreams: true, ClientStreams: true},
+		},
+		Metadata: fileDesc.GetName(),
+	}, struct{}{})
+	return nil
+}
@@ -181,14 +181,18 @@ func registerTestUserService(server *grpc.Server) error {
 		},
 	}
 
-	// Build file descriptor
-	fd, err := protodesc.NewFile(fileDesc, protoregistry.GlobalFiles)
+	// Reuse the registered file when another test already started the service
+	fd, err := protoregistry.GlobalFiles.FindFileByPath(fileDesc.GetName())
 	if err != nil {
-		return fmt.Errorf("failed to create file descriptor: %w", err)
-	}
+		// Build file descriptor
+		fd, err = protodesc.NewFile(fileDesc, protoregistry.GlobalFiles)
+		if err != nil {
+			return fmt.Errorf("failed to create file descriptor: %w", err)
+		}
 
-	// Try to register (ignore if already registered from demo)
-	_ = protoregistry.GlobalFiles.RegisterFile(fd)
+		// Try to register (ignore if already registered from demo)
+		_ = protoregistry.GlobalFiles.RegisterFile(fd)
+	}
 
 	// Get service and method descriptors
 	services := fd.Services()
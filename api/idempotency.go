@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultIdempotencyKeyHeader is the header carrying the idempotency key
+const DefaultIdempotencyKeyHeader = "Idempotency-Key"
+
+// isHTTPMethodEndpoint reports whether retries of the endpoint type depend on the HTTP method
+// SOAP operations and gRPC methods carry no HTTP method semantics.
+func isHTTPMethodEndpoint(endpointType EndpointType) bool {
+	switch endpointType {
+	case EndpointTypeREST, EndpointTypeFormURLEncoded, EndpointTypeMultipart, EndpointTypeGraphQL:
+		return true
+	default:
+		return false
+	}
+}
+
+// effectiveMethod returns the HTTP method the client will send for method
+func (e *Endpoint) effectiveMethod(method string) string {
+	if e.Type == EndpointTypeGraphQL {
+		return http.MethodPost
+	}
+	if method == "" {
+		if e.Type == EndpointTypeMultipart {
+			return http.MethodPost
+		}
+		return http.MethodGet
+	}
+	return strings.ToUpper(method)
+}
+
+// isIdempotentMethod reports whether repeating a request with method has no additional effect (RFC 9110)
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotencyKeyHeader returns the configured idempotency key header name
+func (e *Endpoint) idempotencyKeyHeader() string {
+	if e.IdempotencyKeyHeader != "" {
+		return e.IdempotencyKeyHeader
+	}
+	return DefaultIdempotencyKeyHeader
+}
+
+// withIdempotencyKey returns headers with a new idempotency key unless one is set
+// The caller's map is copied, so the key is stable across attempts of one call only.
+func (e *Endpoint) withIdempotencyKey(headers map[string]string) (map[string]string, error) {
+	name := e.idempotencyKeyHeader()
+	if headerValue(headers, name) != "" {
+		return headers, nil
+	}
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	keyed := make(map[string]string, len(headers)+1)
+	maps.Copy(keyed, headers)
+	keyed[name] = key
+	return keyed, nil
+}
+
+// retryAllowed reports whether a failed attempt may be retried without risking
+// a duplicate mutation: idempotent methods, keyed requests, opted-in endpoints,
+// and failures that happened before the request was sent
+func (e *Endpoint) retryAllowed(options *CallOptions, err error) bool {
+	return e.resendAllowed(options) || requestNotSent(err)
+}
+
+// resendAllowed reports whether a request that reached the server may be sent again
+func (e *Endpoint) resendAllowed(options *CallOptions) bool {
+	if e.RetryNonIdempotent || !isHTTPMethodEndpoint(e.Type) {
+		return true
+	}
+	if isIdempotentMethod(e.effectiveMethod(options.Method)) {
+		return true
+	}
+	return headerValue(options.Headers, e.idempotencyKeyHeader()) != ""
+}
+
+// requestNotSent reports whether err guarantees the server never received the request
+func requestNotSent(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// newIdempotencyKey returns a random UUID (version 4)
+func newIdempotencyKey() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
+
+// maxReplayBufferBytes caps how much of a non-seekable request body is kept for retries
+const maxReplayBufferBytes = 1 << 20
+
+// rewindableBody replays an io.Reader request body on every attempt
+// Seekable readers are rewound to their starting offset; other readers are
+// buffered as they are read, up to limit bytes, and a retry replays the buffer
+// before the unread rest. Once more than limit bytes are read the buffer is
+// dropped and the body can no longer be replayed.
+type rewindableBody struct {
+	source   io.Reader
+	seeker   io.Seeker
+	start    int64
+	buffer   bytes.Buffer
+	limit    int
+	overflow bool
+	reads    int
+}
+
+// newRewindableBody wraps body, buffering up to limit bytes of a non-seekable
+// reader, and returns nil if body is not a plain io.Reader
+func newRewindableBody(body any, limit int) *rewindableBody {
+	switch body.(type) {
+	case nil, []byte, string:
+		return nil
+	}
+	reader, ok := body.(io.Reader)
+	if !ok {
+		return nil
+	}
+	rewindable := &rewindableBody{source: reader, limit: limit}
+	if seeker, ok := reader.(io.Seeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			rewindable.seeker = seeker
+			rewindable.start = start
+		}
+	}
+	return rewindable
+}
+
+// reader returns the body for the next attempt
+// The returned reader hides Close, so the source is only closed by close.
+func (b *rewindableBody) reader() (io.Reader, error) {
+	b.reads++
+	if b.seeker != nil {
+		if b.reads > 1 {
+			if _, err := b.seeker.Seek(b.start, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+		}
+		return struct{ io.Reader }{b.source}, nil
+	}
+	if b.overflow {
+		return nil, errors.New("request body is too large to be sent again")
+	}
+	// Replay what earlier attempts consumed, then keep recording the rest
+	replay := bytes.NewReader(bytes.Clone(b.buffer.Bytes()))
+	return io.MultiReader(replay, io.TeeReader(b.source, b)), nil
+}
+
+// Write records body bytes read by an attempt, dropping them once limit is exceeded
+func (b *rewindableBody) Write(p []byte) (int, error) {
+	if b.overflow {
+		return len(p), nil
+	}
+	if b.buffer.Len()+len(p) > b.limit {
+		b.overflow = true
+		b.buffer = bytes.Buffer{}
+		return len(p), nil
+	}
+	return b.buffer.Write(p)
+}
+
+// replayable reports whether the body can be sent again
+func (b *rewindableBody) replayable() bool {
+	return b.seeker != nil || !b.overflow
+}
+
+// close closes the source body if it is an io.Closer
+func (b *rewindableBody) close() {
+	closeBody(b.source)
+}
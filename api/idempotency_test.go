@@ -0,0 +1,228 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failFirstServer fails the first request of each call with 503 and records bodies and headers
+type failFirstServer struct {
+	mu      sync.Mutex
+	bodies  []string
+	headers []http.Header
+}
+
+func (s *failFirstServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(body))
+	s.headers = append(s.headers, r.Header.Clone())
+	attempt := len(s.bodies)
+	s.mu.Unlock()
+	if attempt%2 == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+// reset clears the recorded requests
+func (s *failFirstServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = nil
+	s.headers = nil
+}
+
+// retryEndpoint returns an endpoint retrying 503 responses once
+func retryEndpoint(name string, url string) Endpoint {
+	return Endpoint{
+		FriendlyName: name,
+		URL:          url,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+		RetryPolicy: &BackoffRetryPolicy{
+			MaxRetries:       1,
+			InitialBackoff:   time.Millisecond,
+			RetryStatusCodes: []int{http.StatusServiceUnavailable},
+		},
+	}
+}
+
+// TestRetryIdempotentMethodsOnly verifies non-idempotent methods are not retried by default
+func TestRetryIdempotentMethodsOnly(t *testing.T) {
+	handler := &failFirstServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	endpoint := retryEndpoint("Test Idempotent Retry", server.URL)
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	for _, tc := range []struct {
+		method   string
+		attempts int
+	}{
+		{http.MethodPost, 1},
+		{http.MethodPatch, 1},
+		{http.MethodPut, 2},
+		{http.MethodDelete, 2},
+		{"", 2},
+	} {
+		handler.reset()
+		endpoint.Call(map[string]any{"method": tc.method, "body": "data"})
+		if len(handler.bodies) != tc.attempts {
+			t.Errorf("Expected %d attempts for %q, got %d", tc.attempts, tc.method, len(handler.bodies))
+		}
+	}
+
+	// Opting in retries every method
+	handler.reset()
+	endpoint.RetryNonIdempotent = true
+	if _, err := endpoint.Call(map[string]any{"method": "POST", "body": "data"}); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if len(handler.bodies) != 2 {
+		t.Errorf("Expected 2 attempts with RetryNonIdempotent, got %d", len(handler.bodies))
+	}
+}
+
+// TestIdempotencyKey verifies a stable key is sent across attempts and enables retries
+func TestIdempotencyKey(t *testing.T) {
+	handler := &failFirstServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	endpoint := retryEndpoint("Test Idempotency Key", server.URL)
+	endpoint.IdempotencyKey = true
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	headers := map[string]string{"X-Trace": "t1"}
+	if _, err := endpoint.Call(map[string]any{"method": "POST", "body": "data", "headers": headers}); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if len(handler.headers) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(handler.headers))
+	}
+	key := handler.headers[0].Get("Idempotency-Key")
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(key) {
+		t.Errorf("Expected a UUID idempotency key, got %q", key)
+	}
+	if handler.headers[1].Get("Idempotency-Key") != key {
+		t.Errorf("Expected the same key on retry, got %q and %q", key, handler.headers[1].Get("Idempotency-Key"))
+	}
+	if len(headers) != 1 {
+		t.Errorf("Expected caller headers to be left unmodified, got %v", headers)
+	}
+
+	// A new call gets a new key; a caller-supplied key is kept
+	handler.reset()
+	endpoint.Call(map[string]any{"method": "POST", "body": "data"})
+	if handler.headers[0].Get("Idempotency-Key") == key {
+		t.Error("Expected a new key for a new call")
+	}
+	handler.reset()
+	endpoint.IdempotencyKey = false
+	endpoint.Call(map[string]any{"method": "POST", "headers": map[string]string{"idempotency-key": "order-7"}})
+	if len(handler.headers) != 2 || handler.headers[1].Get("Idempotency-Key") != "order-7" {
+		t.Errorf("Expected caller key to enable retries, got %d attempts", len(handler.headers))
+	}
+
+	// Idempotent methods are not keyed
+	handler.reset()
+	endpoint.IdempotencyKey = true
+	endpoint.Call(map[string]any{"method": "GET"})
+	if handler.headers[0].Get("Idempotency-Key") != "" {
+		t.Error("Expected no idempotency key for GET")
+	}
+}
+
+// closeTrackingReader is a non-seekable body recording Close
+type closeTrackingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeTrackingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+// TestRewindableBody verifies io.Reader bodies are sent in full on every attempt
+func TestRewindableBody(t *testing.T) {
+	handler := &failFirstServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	endpoint := retryEndpoint("Test Rewindable Body", server.URL)
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	streamed := &closeTrackingReader{Reader: io.MultiReader(strings.NewReader("streamed "), strings.NewReader("payload"))}
+	seekable := strings.NewReader("skip:seekable payload")
+	seekable.Seek(5, io.SeekStart)
+
+	for _, body := range []io.Reader{streamed, seekable} {
+		handler.reset()
+		if _, err := endpoint.Call(map[string]any{"method": "PUT", "body": body}); err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if len(handler.bodies) != 2 || handler.bodies[0] != handler.bodies[1] || !strings.HasSuffix(handler.bodies[1], "payload") {
+			t.Errorf("Expected the full body on both attempts, got %q", handler.bodies)
+		}
+	}
+	if handler.bodies[1] != "seekable payload" {
+		t.Errorf("Expected the seekable body from its starting offset, got %q", handler.bodies[1])
+	}
+	if !streamed.closed {
+		t.Error("Expected the body to be closed after the call")
+	}
+
+	// A non-seekable body larger than the replay buffer is sent once
+	handler.reset()
+	large := struct{ io.Reader }{strings.NewReader(strings.Repeat("x", maxReplayBufferBytes+1))}
+	if _, err := endpoint.Call(map[string]any{"method": "PUT", "body": large}); err == nil {
+		t.Error("Expected the 503 of the only attempt")
+	}
+	if len(handler.bodies) != 1 || len(handler.bodies[0]) != maxReplayBufferBytes+1 {
+		t.Errorf("Expected a single full attempt, got %d attempts", len(handler.bodies))
+	}
+
+	// Bodies of requests that may not be resent are not buffered
+	rewindable := newRewindableBody(struct{ io.Reader }{strings.NewReader("post payload")}, 0)
+	reader, _ := rewindable.reader()
+	io.ReadAll(reader)
+	if rewindable.replayable() || rewindable.buffer.Len() != 0 {
+		t.Errorf("Expected an unbuffered body that cannot be replayed, got %d buffered bytes", rewindable.buffer.Len())
+	}
+}
+
+// TestRequestNotSent verifies failures before sending allow retrying mutations
+func TestRequestNotSent(t *testing.T) {
+	endpoint := Endpoint{Type: EndpointTypeREST}
+	options := &CallOptions{Method: http.MethodPost}
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	if !endpoint.retryAllowed(options, dialErr) {
+		t.Error("Expected dial failures to be retryable")
+	}
+	if !endpoint.retryAllowed(options, &CircuitOpenError{FriendlyName: "x"}) {
+		t.Error("Expected circuit breaker rejections to be retryable")
+	}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}
+	if endpoint.retryAllowed(options, readErr) {
+		t.Error("Expected read failures of a POST not to be retryable")
+	}
+
+	// SOAP and gRPC calls have no HTTP method semantics
+	endpoint.Type = EndpointTypeGRPC
+	if !endpoint.retryAllowed(options, readErr) {
+		t.Error("Expected gRPC calls to be governed by the retry policy alone")
+	}
+}
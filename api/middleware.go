@@ -0,0 +1,228 @@
+package api
+
+import (
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler executes a call against an endpoint
+// The context of the call is options.Context.
+type Handler func(endpoint Endpoint, options *CallOptions) (*Response, error)
+
+// Middleware wraps a Handler to intercept calls of every endpoint type
+// Middleware may inspect or mutate options before calling next, and inspect or
+// replace the response after. Headers are sent as HTTP headers for REST/SOAP and
+// as outgoing metadata for gRPC. Authentication is applied after the middleware,
+// so credentials are never visible to it.
+type Middleware func(next Handler) Handler
+
+var (
+	globalMiddleware      []Middleware
+	globalMiddlewareMutex sync.RWMutex
+)
+
+// UseMiddleware registers middleware applied to calls of every endpoint
+// Global middleware runs before (outside) endpoint and per-call middleware,
+// in registration order.
+func UseMiddleware(middleware ...Middleware) {
+	globalMiddlewareMutex.Lock()
+	defer globalMiddlewareMutex.Unlock()
+	globalMiddleware = append(globalMiddleware, middleware...)
+}
+
+// ClearMiddleware removes all global middleware
+func ClearMiddleware() {
+	globalMiddlewareMutex.Lock()
+	defer globalMiddlewareMutex.Unlock()
+	globalMiddleware = nil
+}
+
+// chainMiddleware wraps handler with the global middleware, then middleware
+// The first middleware is the outermost.
+func chainMiddleware(handler Handler, middleware []Middleware) Handler {
+	globalMiddlewareMutex.RLock()
+	chain := slices.Concat(globalMiddleware, middleware)
+	globalMiddlewareMutex.RUnlock()
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i] != nil {
+			handler = chain[i](handler)
+		}
+	}
+	return handler
+}
+
+// HeaderMiddleware returns middleware adding headers to every call
+// Headers already set on the call take precedence. The call's header map is
+// copied, so maps passed in by callers are not modified.
+func HeaderMiddleware(headers map[string]string) Middleware {
+	return func(next Handler) Handler {
+		return func(endpoint Endpoint, options *CallOptions) (*Response, error) {
+			merged := make(map[string]string, len(headers)+len(options.Headers))
+			maps.Copy(merged, headers)
+			maps.Copy(merged, options.Headers)
+			options.Headers = merged
+			return next(endpoint, options)
+		}
+	}
+}
+
+// sensitiveHeaders are always redacted by RedactHeaders
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// RedactHeaders returns a copy of headers with secret values replaced by "[REDACTED]"
+// Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-Api-Key and X-Auth-Token
+// are always redacted, along with any extra header names (case-insensitive).
+func RedactHeaders(headers map[string]string, extra ...string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if isSensitiveHeader(name, extra) {
+			value = "[REDACTED]"
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// redactHeaderValues is RedactHeaders for multi-valued headers such as responses and trailers
+func redactHeaderValues(headers map[string][]string, extra []string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if isSensitiveHeader(name, extra) {
+			values = []string{"[REDACTED]"}
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// isSensitiveHeader reports whether name is a default or extra sensitive header
+func isSensitiveHeader(name string, extra []string) bool {
+	return isSensitiveName(name, sensitiveHeaders, extra)
+}
+
+// isSensitiveName reports whether name is one of defaults or extra (case-insensitive)
+func isSensitiveName(name string, defaults []string, extra []string) bool {
+	for _, sensitive := range slices.Concat(defaults, extra) {
+		if strings.EqualFold(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitiveQueryParams are always redacted by RedactURL
+var sensitiveQueryParams = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"client_secret",
+	"key",
+	"password",
+	"secret",
+	"sig",
+	"signature",
+	"token",
+}
+
+// RedactURL returns rawURL with userinfo removed and secret query values replaced by "[REDACTED]"
+// access_token, api_key, apikey, client_secret, key, password, secret, sig, signature and token
+// are always redacted, along with any extra parameter names (case-insensitive).
+// An unparseable URL is redacted entirely.
+func RedactURL(rawURL string, extra ...string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "[REDACTED]"
+	}
+	parsed.User = nil
+	if parsed.RawQuery != "" {
+		// Rewrite in place to keep the parameter order and encoding of the call
+		parts := strings.Split(parsed.RawQuery, "&")
+		for i, part := range parts {
+			name, _, _ := strings.Cut(part, "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if isSensitiveName(name, sensitiveQueryParams, extra) {
+				parts[i] = url.QueryEscape(name) + "=[REDACTED]"
+			}
+		}
+		parsed.RawQuery = strings.Join(parts, "&")
+	}
+	return parsed.String()
+}
+
+// urlInText matches URLs embedded in error text, e.g. the quoted URL of a *url.Error
+var urlInText = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s"']+`)
+
+// redactErrorText returns the text of err with every URL in it passed through RedactURL
+func redactErrorText(err error, extra ...string) string {
+	return urlInText.ReplaceAllStringFunc(err.Error(), func(match string) string {
+		return RedactURL(match, extra...)
+	})
+}
+
+// LoggingMiddleware returns middleware logging each call to logger
+// Request headers are logged with RedactHeaders(headers, redact...) and the URL with
+// RedactURL(url, redact...), so redact names extra headers and query parameters alike.
+// URLs in the error text, such as those of transport errors, are redacted the same way.
+// Bodies are never logged.
+// If logger is nil, the standard logger is used.
+func LoggingMiddleware(logger *log.Logger, redact ...string) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next Handler) Handler {
+		return func(endpoint Endpoint, options *CallOptions) (*Response, error) {
+			start := time.Now()
+			response, err := next(endpoint, options)
+
+			statusCode := 0
+			if response != nil {
+				statusCode = response.StatusCode
+			}
+			headers := formatHeaders(RedactHeaders(options.Headers, redact...))
+			requestURL := RedactURL(callURL(endpoint, options), redact...)
+			if err != nil {
+				logger.Printf("api: %s %s %s status=%d duration=%s headers=%s error=%v",
+					endpoint.FriendlyName, options.Method, requestURL, statusCode, time.Since(start), headers, redactErrorText(err, redact...))
+			} else {
+				logger.Printf("api: %s %s %s status=%d duration=%s headers=%s",
+					endpoint.FriendlyName, options.Method, requestURL, statusCode, time.Since(start), headers)
+			}
+			return response, err
+		}
+	}
+}
+
+// formatHeaders renders headers in sorted, canonical form for logging
+func formatHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, http.CanonicalHeaderKey(name)+": "+headers[name])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// recordingMiddleware appends enter/exit markers for name to trace
+func recordingMiddleware(name string, trace *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(endpoint Endpoint, options *CallOptions) (*Response, error) {
+			*trace = append(*trace, name+">")
+			response, err := next(endpoint, options)
+			*trace = append(*trace, "<"+name)
+			return response, err
+		}
+	}
+}
+
+// TestMiddlewareChain verifies global and endpoint middleware order and request mutation
+func TestMiddlewareChain(t *testing.T) {
+	var tenant, trace atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant.Store(r.Header.Get("X-Tenant"))
+		trace.Store(r.Header.Get("X-Trace"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	UseMiddleware(recordingMiddleware("global", &order))
+	defer ClearMiddleware()
+
+	var statusCodes []int
+	metrics := func(next Handler) Handler {
+		return func(endpoint Endpoint, options *CallOptions) (*Response, error) {
+			response, err := next(endpoint, options)
+			if response != nil {
+				statusCodes = append(statusCodes, response.StatusCode)
+			}
+			return response, err
+		}
+	}
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Middleware",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+		Middleware: []Middleware{
+			recordingMiddleware("endpoint", &order),
+			HeaderMiddleware(map[string]string{"X-Tenant": "acme", "X-Trace": "default"}),
+			metrics,
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	headers := map[string]string{"X-Trace": "abc"}
+	if _, err := endpoint.Call(map[string]any{"method": "GET", "headers": headers}); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	if tenant.Load() != "acme" {
+		t.Errorf("Expected injected X-Tenant header, got %v", tenant.Load())
+	}
+	if trace.Load() != "abc" {
+		t.Errorf("Expected call header to take precedence, got %v", trace.Load())
+	}
+	if len(headers) != 1 {
+		t.Errorf("Expected caller headers to be left unmodified, got %v", headers)
+	}
+	expected := "global>,endpoint>,<endpoint,<global"
+	if strings.Join(order, ",") != expected {
+		t.Errorf("Expected middleware order %s, got %s", expected, strings.Join(order, ","))
+	}
+	if len(statusCodes) != 1 || statusCodes[0] != http.StatusOK {
+		t.Errorf("Expected one observed 200 response, got %v", statusCodes)
+	}
+
+	// Endpoint middleware is not shared through the cached caller
+	order = nil
+	plain := endpoint
+	plain.Middleware = nil
+	if _, err := plain.Call(nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if strings.Join(order, ",") != "global>,<global" {
+		t.Errorf("Expected only global middleware, got %s", strings.Join(order, ","))
+	}
+}
+
+// TestMiddlewareGRPCHeaders verifies middleware headers are sent as gRPC metadata
+func TestMiddlewareGRPCHeaders(t *testing.T) {
+	var tenant atomic.Value
+	server, port, err := startTestAuthServer(func(md metadata.MD) {
+		if value := firstMetadataValue(md, "x-tenant"); value != "" {
+			tenant.Store(value)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test gRPC Middleware",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/meta.MetaService/Check",
+		Timeout:      10 * time.Second,
+		Config:       &APICallerConfig{InsecureSkipVerify: true},
+		Middleware:   []Middleware{HeaderMiddleware(map[string]string{"X-Tenant": "acme"})},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	if _, err := endpoint.Call(map[string]any{"body": map[string]any{"name": "ok"}}); err != nil {
+		t.Fatalf("gRPC call failed: %v", err)
+	}
+	if tenant.Load() != "acme" {
+		t.Errorf("Expected x-tenant metadata, got %v", tenant.Load())
+	}
+}
+
+// TestLoggingMiddlewareRedaction verifies secrets are redacted from logged headers and URLs
+func TestLoggingMiddlewareRedaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var buffer bytes.Buffer
+	endpoint := Endpoint{
+		FriendlyName: "Test Logging",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+		Middleware:   []Middleware{LoggingMiddleware(log.New(&buffer, "", 0), "X-Session")},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	endpoint.Call(map[string]any{
+		"method":  "GET",
+		"baseURL": strings.Replace(server.URL, "://", "://user:secret-password@", 1),
+		"query":   map[string]string{"api_key": "secret-key", "X-Session": "secret-query", "page": "2"},
+		"headers": map[string]string{
+			"Authorization": "Bearer secret-token",
+			"x-session":     "secret-session",
+			"X-Request-Id":  "req-1",
+		},
+	})
+
+	logged := buffer.String()
+	for _, secret := range []string{"secret-token", "secret-session", "secret-password", "secret-key", "secret-query", "user@"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %s to be redacted, got %s", secret, logged)
+		}
+	}
+	for _, expected := range []string{"Test Logging GET", "status=404", "X-Request-Id: req-1", "Authorization: [REDACTED]", "X-Session: [REDACTED]", "api_key=[REDACTED]", "page=2", "error="} {
+		if !strings.Contains(logged, expected) {
+			t.Errorf("Expected log to contain %q, got %s", expected, logged)
+		}
+	}
+
+	// Transport errors quote the request URL
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	unreachable := listener.Addr().String()
+	listener.Close()
+	buffer.Reset()
+	if _, err := endpoint.Call(map[string]any{
+		"baseURL": "http://user:secret-password@" + unreachable,
+		"query":   map[string]string{"token": "secret-token", "page": "3"},
+	}); err == nil || !strings.Contains(err.Error(), "secret-token") {
+		t.Fatalf("Expected a transport error quoting the URL, got %v", err)
+	}
+	logged = buffer.String()
+	if strings.Contains(logged, "secret-token") || strings.Contains(logged, "secret-password") {
+		t.Errorf("Expected the error text to be redacted, got %s", logged)
+	}
+	if !strings.Contains(logged, "error=Get \"http://"+unreachable+"?page=3&token=[REDACTED]\"") {
+		t.Errorf("Expected the redacted URL in the error, got %s", logged)
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/trimble-oss/tierceron-core/v2/trcshfs/trcshio"
+)
+
+// MultipartFile is a file part of a multipart/form-data request
+// Exactly one of Data, Reader or MemFs/Path supplies the content.
+type MultipartFile struct {
+	// FieldName is the form field name of the part
+	FieldName string
+	// FileName is the file name sent to the server
+	// If unset, the base name of Path is used
+	FileName string
+	// ContentType is the part content type
+	// If unset, it is derived from the file name extension, defaulting to application/octet-stream
+	ContentType string
+	// Data is the file content held in memory
+	Data []byte
+	// Reader streams the file content
+	// A Reader can only be sent once, so calls using it are not retried successfully
+	Reader io.Reader
+	// MemFs and Path read the file content from a memory filesystem such as trcshfs.TrcshMemFs
+	MemFs trcshio.MemoryFileSystem
+	Path  string
+}
+
+// MultipartBody is the body of a multipart/form-data request
+type MultipartBody struct {
+	// Fields are plain form fields, written in sorted key order before the files
+	Fields map[string]string
+	// Files are the file parts, written in order
+	Files []MultipartFile
+}
+
+// encodeMultipartBody streams body as multipart/form-data
+// It returns the body reader and the Content-Type including the boundary.
+// Accepted bodies are MultipartBody, *MultipartBody and map[string]any, where
+// MultipartFile values (or slices of them) become file parts and other values fields.
+func encodeMultipartBody(body any) (io.Reader, string, error) {
+	parts, err := multipartParts(body)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range parts.Files {
+		if err := parts.Files[i].validate(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeMultipart(writer, parts))
+	}()
+
+	return pr, writer.FormDataContentType(), nil
+}
+
+// multipartParts normalizes the supported body types into a MultipartBody
+func multipartParts(body any) (*MultipartBody, error) {
+	switch v := body.(type) {
+	case *MultipartBody:
+		return v, nil
+	case MultipartBody:
+		return &v, nil
+	case map[string]string:
+		return &MultipartBody{Fields: v}, nil
+	case map[string]any:
+		parts := &MultipartBody{Fields: make(map[string]string)}
+		for key, value := range v {
+			switch field := value.(type) {
+			case MultipartFile:
+				parts.Files = append(parts.Files, field.withFieldName(key))
+			case *MultipartFile:
+				parts.Files = append(parts.Files, field.withFieldName(key))
+			case []MultipartFile:
+				for _, file := range field {
+					parts.Files = append(parts.Files, file.withFieldName(key))
+				}
+			default:
+				parts.Fields[key] = fmt.Sprint(value)
+			}
+		}
+		// Map iteration order is random; keep file parts deterministic
+		slices.SortStableFunc(parts.Files, func(a, b MultipartFile) int {
+			return strings.Compare(a.FieldName, b.FieldName)
+		})
+		return parts, nil
+	default:
+		return nil, fmt.Errorf("unsupported multipart body type: %T", body)
+	}
+}
+
+// writeMultipart writes the fields and files of parts to writer
+func writeMultipart(writer *multipart.Writer, parts *MultipartBody) error {
+	keys := make([]string, 0, len(parts.Fields))
+	for key := range parts.Fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if err := writer.WriteField(key, parts.Fields[key]); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range parts.Files {
+		if err := file.write(writer); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// withFieldName returns a copy of f using name if no field name is set
+func (f MultipartFile) withFieldName(name string) MultipartFile {
+	if f.FieldName == "" {
+		f.FieldName = name
+	}
+	return f
+}
+
+// validate checks that exactly one content source is configured
+func (f *MultipartFile) validate() error {
+	if f.FieldName == "" {
+		return fmt.Errorf("multipart file requires a FieldName")
+	}
+	sources := 0
+	if f.Data != nil {
+		sources++
+	}
+	if f.Reader != nil {
+		sources++
+	}
+	if f.MemFs != nil || f.Path != "" {
+		if f.MemFs == nil || f.Path == "" {
+			return fmt.Errorf("multipart file '%s' requires both MemFs and Path", f.FieldName)
+		}
+		sources++
+	}
+	if sources != 1 {
+		return fmt.Errorf("multipart file '%s' must have exactly one of Data, Reader or MemFs/Path", f.FieldName)
+	}
+	return nil
+}
+
+// write copies the file content into a new part of writer
+func (f *MultipartFile) write(writer *multipart.Writer) error {
+	fileName := f.FileName
+	if fileName == "" && f.Path != "" {
+		fileName = path.Base(f.Path)
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(fileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(f.FieldName), escapeQuotes(fileName)))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	var source io.Reader
+	switch {
+	case f.Data != nil:
+		source = bytes.NewReader(f.Data)
+	case f.Reader != nil:
+		source = f.Reader
+	default:
+		file, err := f.MemFs.Open(f.Path)
+		if err != nil {
+			return fmt.Errorf("failed to open memfs file '%s': %w", f.Path, err)
+		}
+		defer file.Close()
+		source = file
+	}
+
+	if _, err := io.Copy(part, source); err != nil {
+		return fmt.Errorf("failed to write multipart file '%s': %w", f.FieldName, err)
+	}
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes a Content-Disposition parameter value
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
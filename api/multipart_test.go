@@ -0,0 +1,144 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/trimble-oss/tierceron-core/v2/trcshfs"
+)
+
+// TestMultipartUpload verifies fields and files from bytes, readers and memfs are uploaded
+func TestMultipartUpload(t *testing.T) {
+	memFs := trcshfs.NewTrcshMemFs()
+	file, err := memFs.Create("artifacts/build.json")
+	if err != nil {
+		t.Fatalf("Failed to create memfs file: %v", err)
+	}
+	file.Write([]byte(`{"build":42}`))
+	file.Close()
+
+	var calls atomic.Int32
+	received := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt fails so the body must be encoded again on retry
+		if calls.Add(1) == 1 {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method != http.MethodPost || r.ContentLength != -1 {
+			t.Errorf("Expected a chunked POST, got %s with length %d", r.Method, r.ContentLength)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received["version"] = r.FormValue("version")
+		for field, headers := range r.MultipartForm.File {
+			f, _ := headers[0].Open()
+			data, _ := io.ReadAll(f)
+			f.Close()
+			received[field] = headers[0].Filename + "|" + headers[0].Header.Get("Content-Type") + "|" + string(data)
+		}
+		w.Write([]byte(`{"uploaded":true}`))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Multipart",
+		URL:          server.URL,
+		Type:         EndpointTypeMultipart,
+		Timeout:      5 * time.Second,
+		// Uploads are only retried with an idempotency key
+		IdempotencyKey: true,
+		RetryPolicy: &BackoffRetryPolicy{
+			MaxRetries:       1,
+			InitialBackoff:   time.Millisecond,
+			RetryStatusCodes: []int{http.StatusServiceUnavailable},
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	result, err := endpoint.Call(map[string]any{
+		"body": &MultipartBody{
+			Fields: map[string]string{"version": "1.2.3"},
+			Files: []MultipartFile{
+				{FieldName: "notes", FileName: "notes.txt", Data: []byte("release notes")},
+				{FieldName: "manifest", MemFs: memFs, Path: "artifacts/build.json"},
+				{FieldName: "blob", FileName: "blob.bin", Data: []byte{1, 2, 3}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Multipart upload failed: %v", err)
+	}
+	if body, _ := result["body"].(map[string]any); body["uploaded"] != true {
+		t.Errorf("Unexpected response: %v", result["body"])
+	}
+
+	if received["version"] != "1.2.3" {
+		t.Errorf("Expected version field, got %q", received["version"])
+	}
+	if received["notes"] != "notes.txt|text/plain; charset=utf-8|release notes" {
+		t.Errorf("Unexpected notes part: %q", received["notes"])
+	}
+	if received["manifest"] != `build.json|application/json|{"build":42}` {
+		t.Errorf("Unexpected manifest part: %q", received["manifest"])
+	}
+	if received["blob"] != "blob.bin|application/octet-stream|\x01\x02\x03" {
+		t.Errorf("Unexpected blob part: %q", received["blob"])
+	}
+}
+
+// TestMultipartMapBody verifies map bodies with reader file parts
+func TestMultipartMapBody(t *testing.T) {
+	var fields, files string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		fields = r.FormValue("name") + "," + r.FormValue("count")
+		header := r.MultipartForm.File["upload"][0]
+		f, _ := header.Open()
+		data, _ := io.ReadAll(f)
+		f.Close()
+		files = header.Filename + ":" + string(data)
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{FriendlyName: "Test Multipart Map", URL: server.URL, Type: EndpointTypeMultipart}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	_, err := endpoint.Call(map[string]any{
+		"body": map[string]any{
+			"name":   "report",
+			"count":  3,
+			"upload": MultipartFile{FileName: "report.csv", Reader: strings.NewReader("a,b\n1,2\n")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Multipart upload failed: %v", err)
+	}
+	if fields != "report,3" || files != "report.csv:a,b\n1,2\n" {
+		t.Errorf("Unexpected upload: fields=%q files=%q", fields, files)
+	}
+}
+
+// TestMultipartInvalidFile verifies file parts need exactly one content source
+func TestMultipartInvalidFile(t *testing.T) {
+	_, _, err := encodeMultipartBody(&MultipartBody{
+		Files: []MultipartFile{{FieldName: "f", Data: []byte("x"), Reader: strings.NewReader("y")}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "exactly one") {
+		t.Errorf("Expected content source error, got %v", err)
+	}
+	_, _, err = encodeMultipartBody(&MultipartBody{
+		Files: []MultipartFile{{FieldName: "f", Path: "missing.txt"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "MemFs and Path") {
+		t.Errorf("Expected MemFs error, got %v", err)
+	}
+}
@@ -0,0 +1,413 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxPages is the page limit of Paginate without PaginateOptions.MaxPages
+const defaultMaxPages = 100
+
+// ErrMaxPages is returned by Paginate when more pages remain after PaginateOptions.MaxPages
+var ErrMaxPages = errors.New("maximum number of pages reached")
+
+// ErrCrossOriginLink is returned by LinkPagination when a next link points to another scheme or host
+var ErrCrossOriginLink = errors.New("cross-origin next link")
+
+// Page is one page of a paginated call
+type Page struct {
+	// Number is the 1-based page number
+	Number int
+	// URL is the resolved URL the page was requested from
+	URL string
+	// Params are the Endpoint.Call params the page was requested with
+	Params map[string]any
+	// Result is the result map of the page call
+	Result map[string]any
+	// Items are the items of the page
+	Items []any
+}
+
+// PageStrategy decides which params request each page
+type PageStrategy interface {
+	// First returns the params of the first page
+	First(params map[string]any) (map[string]any, error)
+	// Next returns the params of the page after page, or nil if page is the last
+	Next(page *Page) (map[string]any, error)
+}
+
+// PaginateOptions configures Endpoint.Paginate
+type PaginateOptions struct {
+	// Strategy selects the next page
+	// If unset, defaults to LinkPagination
+	Strategy PageStrategy
+	// ItemsPath is the dot-separated path of the items array in the response body (e.g. "data.items")
+	// If unset, the body itself must be an array
+	ItemsPath string
+	// MaxPages guards against endless pagination; more pages fail with ErrMaxPages
+	// If set to 0 or unset, defaults to 100
+	MaxPages int
+}
+
+// Paginate calls the endpoint page by page, yielding the items of every page
+// Each page is requested with CallContext and the params chosen by options.Strategy.
+// Iteration stops at the first error, which is yielded with a nil item, or at an
+// empty page body; breaking out of the loop stops requesting pages.
+func (e *Endpoint) Paginate(ctx context.Context, params map[string]any, options PaginateOptions) iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		if e == nil {
+			// e.g. Lookup of an endpoint missing from the catalog
+			yield(nil, ErrEndpointNotFound)
+			return
+		}
+		for page, err := range e.pages(ctx, params, options) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pages yields the pages of a paginated call
+func (e *Endpoint) pages(ctx context.Context, params map[string]any, options PaginateOptions) iter.Seq2[*Page, error] {
+	return func(yield func(*Page, error) bool) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		strategy := options.Strategy
+		if strategy == nil {
+			strategy = LinkPagination{}
+		}
+		maxPages := options.MaxPages
+		if maxPages <= 0 {
+			maxPages = defaultMaxPages
+		}
+
+		pageParams, err := strategy.First(params)
+		for number := 1; err == nil && pageParams != nil; number++ {
+			if number > maxPages {
+				err = fmt.Errorf("endpoint '%s': %w (%d)", e.FriendlyName, ErrMaxPages, maxPages)
+				break
+			}
+			if err = ctx.Err(); err != nil {
+				break
+			}
+
+			page := &Page{Number: number, Params: pageParams}
+			if page.URL, err = pageURL(e, pageParams); err != nil {
+				break
+			}
+			if page.Result, err = e.CallContext(ctx, pageParams); err != nil {
+				break
+			}
+			if isEmptyBody(page.Result["body"]) {
+				// e.g. 204 No Content after the last page
+				return
+			}
+			if page.Items, err = pageItems(page.Result["body"], options.ItemsPath); err != nil {
+				err = fmt.Errorf("page %d of endpoint '%s': %w", number, e.FriendlyName, err)
+				break
+			}
+			if !yield(page, nil) {
+				return
+			}
+			pageParams, err = strategy.Next(page)
+		}
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// LinkPagination follows the rel="next" target of RFC 8288 (RFC 5988) Link headers
+// Links to another scheme or host fail with ErrCrossOriginLink, since the next call
+// carries the endpoint's credentials.
+type LinkPagination struct{}
+
+// First implements PageStrategy
+func (LinkPagination) First(params map[string]any) (map[string]any, error) {
+	return copyParams(params), nil
+}
+
+// Next implements PageStrategy
+func (LinkPagination) Next(page *Page) (map[string]any, error) {
+	headers, _ := page.Result["headers"].(map[string][]string)
+	target := nextLink(http.Header(headers).Values("Link"))
+	if target == "" {
+		return nil, nil
+	}
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %w", err)
+	}
+	next, err := base.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid next link '%s': %w", target, err)
+	}
+	// The next call carries the caller's credentials, so it must not leave the origin
+	if !strings.EqualFold(next.Scheme, base.Scheme) || !strings.EqualFold(next.Host, base.Host) {
+		return nil, fmt.Errorf("%w: next link '%s' leaves %s://%s", ErrCrossOriginLink, next.Redacted(), base.Scheme, base.Host)
+	}
+
+	// The link is the complete URL of the next page
+	params := copyParams(page.Params)
+	delete(params, "path")
+	delete(params, "query")
+	delete(params, "baseURL")
+	params["url"] = next.String()
+	return params, nil
+}
+
+// CursorPagination sends the cursor found in each response body as a query parameter
+type CursorPagination struct {
+	// CursorPath is the dot-separated path of the next cursor in the response body (e.g. "meta.nextCursor")
+	// An empty, null or missing cursor ends pagination
+	CursorPath string
+	// Param is the query parameter carrying the cursor
+	// If unset, defaults to "cursor"
+	Param string
+}
+
+// First implements PageStrategy
+func (c CursorPagination) First(params map[string]any) (map[string]any, error) {
+	return copyParams(params), nil
+}
+
+// Next implements PageStrategy
+func (c CursorPagination) Next(page *Page) (map[string]any, error) {
+	cursor, ok := lookupPath(page.Result["body"], c.CursorPath)
+	if !ok || cursor == nil || cursor == "" {
+		return nil, nil
+	}
+	param := c.Param
+	if param == "" {
+		param = "cursor"
+	}
+	return withQueryParam(page.Params, param, formatPageValue(cursor))
+}
+
+// OffsetPagination requests pages of Limit items with offset and limit query parameters
+// A page with fewer than Limit items ends pagination.
+type OffsetPagination struct {
+	// Limit is the page size
+	Limit int
+	// OffsetParam is the offset query parameter
+	// If unset, defaults to "offset"
+	OffsetParam string
+	// LimitParam is the page size query parameter
+	// If unset, defaults to "limit"
+	LimitParam string
+}
+
+// First implements PageStrategy
+func (o OffsetPagination) First(params map[string]any) (map[string]any, error) {
+	if o.Limit <= 0 {
+		return nil, errors.New("offset pagination requires a positive Limit")
+	}
+	params, err := withQueryParam(params, defaultString(o.LimitParam, "limit"), strconv.Itoa(o.Limit))
+	if err != nil {
+		return nil, err
+	}
+	return withQueryParam(params, defaultString(o.OffsetParam, "offset"), "0")
+}
+
+// Next implements PageStrategy
+func (o OffsetPagination) Next(page *Page) (map[string]any, error) {
+	if len(page.Items) < o.Limit {
+		return nil, nil
+	}
+	query, err := queryParams(page.Params["query"])
+	if err != nil {
+		return nil, err
+	}
+	offset, _ := strconv.Atoi(query.Get(defaultString(o.OffsetParam, "offset")))
+	return withQueryParam(page.Params, defaultString(o.OffsetParam, "offset"), strconv.Itoa(offset+len(page.Items)))
+}
+
+// PageNumberPagination requests numbered pages with page and optional size query parameters
+// An empty page, or a page with fewer than Size items when Size is set, ends pagination.
+type PageNumberPagination struct {
+	// FirstPage is the number of the first page
+	// If set to 0 or unset, defaults to 1
+	FirstPage int
+	// Size is the page size, sent as SizeParam when set
+	Size int
+	// PageParam is the page number query parameter
+	// If unset, defaults to "page"
+	PageParam string
+	// SizeParam is the page size query parameter
+	// If unset, defaults to "per_page"
+	SizeParam string
+}
+
+// First implements PageStrategy
+func (p PageNumberPagination) First(params map[string]any) (map[string]any, error) {
+	firstPage := p.FirstPage
+	if firstPage == 0 {
+		firstPage = 1
+	}
+	params, err := withQueryParam(params, defaultString(p.PageParam, "page"), strconv.Itoa(firstPage))
+	if err != nil || p.Size <= 0 {
+		return params, err
+	}
+	return withQueryParam(params, defaultString(p.SizeParam, "per_page"), strconv.Itoa(p.Size))
+}
+
+// Next implements PageStrategy
+func (p PageNumberPagination) Next(page *Page) (map[string]any, error) {
+	if len(page.Items) == 0 || (p.Size > 0 && len(page.Items) < p.Size) {
+		return nil, nil
+	}
+	query, err := queryParams(page.Params["query"])
+	if err != nil {
+		return nil, err
+	}
+	number, _ := strconv.Atoi(query.Get(defaultString(p.PageParam, "page")))
+	return withQueryParam(page.Params, defaultString(p.PageParam, "page"), strconv.Itoa(number+1))
+}
+
+// nextLink returns the target of the rel="next" link in Link header values
+func nextLink(values []string) string {
+	for _, value := range values {
+		for link := range strings.SplitSeq(value, ",") {
+			target, attributes, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for attribute := range strings.SplitSeq(attributes, ";") {
+				name, relation, _ := strings.Cut(strings.TrimSpace(attribute), "=")
+				if !strings.EqualFold(name, "rel") {
+					continue
+				}
+				// rel may hold several space-separated relation types
+				for _, rel := range strings.Fields(strings.Trim(relation, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// pageURL returns the URL a page is requested from, for resolving relative links
+func pageURL(e *Endpoint, params map[string]any) (string, error) {
+	options := &CallOptions{}
+	var err error
+	if options.PathParams, err = pathParams(params["path"]); err != nil {
+		return "", err
+	}
+	if options.Query, err = queryParams(params["query"]); err != nil {
+		return "", err
+	}
+	options.BaseURL, _ = params["baseURL"].(string)
+	options.URL, _ = params["url"].(string)
+	return resolveRequestURL(e.URL, options)
+}
+
+// pageItems extracts the items array at path from a response body
+func pageItems(body any, path string) ([]any, error) {
+	value, ok := lookupPath(body, path)
+	if !ok || value == nil {
+		return nil, fmt.Errorf("no items at '%s'", path)
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("items at '%s' are %T, not an array", path, value)
+	}
+	return items, nil
+}
+
+// isEmptyBody reports whether a result body has no content
+func isEmptyBody(body any) bool {
+	switch v := body.(type) {
+	case nil:
+		return true
+	case []byte:
+		return len(v) == 0
+	case string:
+		return strings.TrimSpace(v) == ""
+	default:
+		return false
+	}
+}
+
+// lookupPath returns the value at a dot-separated path of object keys and array indexes
+// An empty path returns value itself.
+func lookupPath(value any, path string) (any, bool) {
+	if path == "" {
+		return value, true
+	}
+	for segment := range strings.SplitSeq(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			var ok bool
+			if value, ok = v[segment]; !ok {
+				return nil, false
+			}
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// withQueryParam returns a copy of params with the query parameter name set to value
+func withQueryParam(params map[string]any, name string, value string) (map[string]any, error) {
+	query, err := queryParams(params["query"])
+	if err != nil {
+		return nil, err
+	}
+	updated := make(url.Values, len(query)+1)
+	for key, values := range query {
+		updated[key] = append([]string(nil), values...)
+	}
+	updated.Set(name, value)
+
+	copied := copyParams(params)
+	copied["query"] = updated
+	return copied, nil
+}
+
+// copyParams returns a shallow copy of call params
+func copyParams(params map[string]any) map[string]any {
+	copied := make(map[string]any, len(params)+1)
+	maps.Copy(copied, params)
+	return copied
+}
+
+// formatPageValue formats a cursor from a JSON body, keeping large numbers exact
+func formatPageValue(value any) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// defaultString returns value, or fallback if value is empty
+func defaultString(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
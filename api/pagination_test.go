@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pagedItems is the data set served by the pagination test server
+var pagedItems = []int{1, 2, 3, 4, 5, 6, 7}
+
+// pagedServer pages pagedItems by Link header, cursor, offset and page number
+func pagedServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requests []string
+	window := func(start int, size int) []int {
+		start = min(start, len(pagedItems))
+		return pagedItems[start:min(start+size, len(pagedItems))]
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/link":
+			start, _ := strconv.Atoi(query.Get("start"))
+			if start+3 < len(pagedItems) {
+				w.Header().Add("Link", fmt.Sprintf(`</link?start=%d>; rel="next", </link?start=6>; rel="last"`, start+3))
+			}
+			fmt.Fprintf(w, "%v", jsonText(window(start, 3)))
+		case "/cursor":
+			start, _ := strconv.Atoi(query.Get("cursor"))
+			next := any(nil)
+			if start+3 < len(pagedItems) {
+				next = strconv.Itoa(start + 3)
+			}
+			fmt.Fprintf(w, `{"data": {"items": %s}, "meta": {"next": %s}}`, jsonText(window(start, 3)), jsonText(next))
+		case "/offset":
+			offset, _ := strconv.Atoi(query.Get("offset"))
+			limit, _ := strconv.Atoi(query.Get("limit"))
+			fmt.Fprint(w, jsonText(window(offset, limit)))
+		case "/escape":
+			w.Header().Set("Link", `<http://other.test/steal>; rel="next"`)
+			fmt.Fprint(w, "[1]")
+		case "/nocontent":
+			if query.Get("page") == "2" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			fmt.Fprint(w, jsonText(window(0, 3)))
+		case "/pages":
+			page, _ := strconv.Atoi(query.Get("page"))
+			fmt.Fprint(w, jsonText(window((page-1)*3, 3)))
+		}
+	}))
+	return server, &requests
+}
+
+// collectItems drains a Paginate iterator
+func collectItems(t *testing.T, endpoint *Endpoint, params map[string]any, options PaginateOptions) ([]any, error) {
+	t.Helper()
+	var items []any
+	for item, err := range endpoint.Paginate(context.Background(), params, options) {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// TestPaginateStrategies verifies every strategy yields all items from one cached caller
+func TestPaginateStrategies(t *testing.T) {
+	server, requests := pagedServer(t)
+	defer server.Close()
+
+	endpoint := Endpoint{FriendlyName: "Test Pagination", URL: server.URL + "/{kind}", Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	for _, tc := range []struct {
+		kind     string
+		options  PaginateOptions
+		requests []string
+	}{
+		{"link", PaginateOptions{}, []string{"/link?filter=x", "/link?start=3", "/link?start=6"}},
+		{"cursor", PaginateOptions{Strategy: CursorPagination{CursorPath: "meta.next"}, ItemsPath: "data.items"}, []string{"/cursor?filter=x", "/cursor?cursor=3&filter=x", "/cursor?cursor=6&filter=x"}},
+		{"offset", PaginateOptions{Strategy: OffsetPagination{Limit: 4}}, []string{"/offset?filter=x&limit=4&offset=0", "/offset?filter=x&limit=4&offset=4"}},
+		{"pages", PaginateOptions{Strategy: PageNumberPagination{}}, []string{"/pages?filter=x&page=1", "/pages?filter=x&page=2", "/pages?filter=x&page=3", "/pages?filter=x&page=4"}},
+	} {
+		*requests = nil
+		params := map[string]any{"path": map[string]string{"kind": tc.kind}, "query": map[string]string{"filter": "x"}}
+		items, err := collectItems(t, &endpoint, params, tc.options)
+		if err != nil {
+			t.Fatalf("%s: pagination failed: %v", tc.kind, err)
+		}
+		if fmt.Sprint(items) != fmt.Sprint(pagedItems) {
+			t.Errorf("%s: expected items %v, got %v", tc.kind, pagedItems, items)
+		}
+		if fmt.Sprint(*requests) != fmt.Sprint(tc.requests) {
+			t.Errorf("%s: expected requests %v, got %v", tc.kind, tc.requests, *requests)
+		}
+		if _, ok := params["query"].(map[string]string); !ok || len(params) != 2 {
+			t.Errorf("%s: expected the caller's params to be left unmodified, got %v", tc.kind, params)
+		}
+	}
+}
+
+// TestPaginateGuards verifies max pages, early break, cancellation and item errors
+func TestPaginateGuards(t *testing.T) {
+	server, requests := pagedServer(t)
+	defer server.Close()
+
+	endpoint := Endpoint{FriendlyName: "Test Pagination Guards", URL: server.URL + "/link", Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	items, err := collectItems(t, &endpoint, nil, PaginateOptions{MaxPages: 2})
+	if !errors.Is(err, ErrMaxPages) || len(items) != 6 {
+		t.Errorf("Expected ErrMaxPages after 6 items, got %d items and %v", len(items), err)
+	}
+
+	*requests = nil
+	for item := range endpoint.Paginate(context.Background(), nil, PaginateOptions{}) {
+		if item == 2.0 {
+			break
+		}
+	}
+	if len(*requests) != 1 {
+		t.Errorf("Expected breaking out to stop requesting pages, got %d requests", len(*requests))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	*requests = nil
+	for _, err = range endpoint.Paginate(ctx, nil, PaginateOptions{}) {
+		cancel()
+	}
+	if !errors.Is(err, context.Canceled) || len(*requests) != 1 {
+		t.Errorf("Expected cancellation after the first page, got %v after %d requests", err, len(*requests))
+	}
+
+	// Next links must stay on the page origin
+	escape := Endpoint{FriendlyName: "Test Pagination Guards", URL: server.URL + "/escape", Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	defer RemoveCallerFromCache(escape, nil)
+	*requests = nil
+	if items, err := collectItems(t, &escape, nil, PaginateOptions{}); !errors.Is(err, ErrCrossOriginLink) || len(items) != 1 || len(*requests) != 1 {
+		t.Errorf("Expected ErrCrossOriginLink after the first page, got %v", err)
+	}
+
+	// An empty page ends pagination
+	noContent := Endpoint{FriendlyName: "Test Pagination Guards", URL: server.URL + "/nocontent", Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	defer RemoveCallerFromCache(noContent, nil)
+	if items, err := collectItems(t, &noContent, nil, PaginateOptions{Strategy: PageNumberPagination{}}); err != nil || len(items) != 3 {
+		t.Errorf("Expected 3 items and no error, got %v and %v", items, err)
+	}
+
+	var missing *Endpoint
+	if _, err := collectItems(t, missing, nil, PaginateOptions{}); !errors.Is(err, ErrEndpointNotFound) {
+		t.Errorf("Expected ErrEndpointNotFound for a nil endpoint, got %v", err)
+	}
+
+	if _, err := collectItems(t, &endpoint, nil, PaginateOptions{ItemsPath: "data"}); err == nil {
+		t.Error("Expected an error for a missing items path")
+	}
+	if _, err := collectItems(t, &endpoint, nil, PaginateOptions{Strategy: OffsetPagination{}}); err == nil {
+		t.Error("Expected an error for offset pagination without a limit")
+	}
+}
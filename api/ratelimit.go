@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a call is rejected by the client-side rate limiter
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitError is the typed error returned when a fail-fast rate limiter rejects a call
+// It matches ErrRateLimited with errors.Is
+type RateLimitError struct {
+	// FriendlyName is the name of the throttled endpoint
+	FriendlyName string
+	// Reason describes which budget was exhausted ("rate" or "concurrency")
+	Reason string
+}
+
+// Error implements the error interface
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for endpoint '%s': %s budget exhausted", e.FriendlyName, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrRateLimited)
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RateLimitConfig configures client-side throttling for the cached APICaller
+// The limits are shared by every user of the same cached caller
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained token bucket rate
+	// If set to 0 or unset, no rate limit is applied
+	RequestsPerSecond float64
+	// Burst is the token bucket capacity
+	// If set to 0 or unset, defaults to 1
+	Burst int
+	// MaxInFlight is the maximum number of concurrent calls
+	// If set to 0 or unset, concurrency is not limited
+	MaxInFlight int
+	// FailFast rejects calls with a *RateLimitError instead of waiting for budget
+	FailFast bool
+}
+
+// rateLimiter combines a token bucket with a max-in-flight semaphore
+type rateLimiter struct {
+	name     string
+	rate     float64
+	burst    float64
+	failFast bool
+	sem      chan struct{}
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// newRateLimiter creates a rate limiter for the named endpoint
+func newRateLimiter(name string, config RateLimitConfig) *rateLimiter {
+	burst := float64(max(config.Burst, 1))
+	rl := &rateLimiter{
+		name:     name,
+		rate:     max(config.RequestsPerSecond, 0),
+		burst:    burst,
+		failFast: config.FailFast,
+		tokens:   burst,
+		now:      time.Now,
+	}
+	rl.last = rl.now()
+	if config.MaxInFlight > 0 {
+		rl.sem = make(chan struct{}, config.MaxInFlight)
+	}
+	return rl
+}
+
+// acquire waits for (or, when failing fast, checks) rate and concurrency budget
+// The returned release function must be called once the call completes
+func (rl *rateLimiter) acquire(ctx context.Context) (func(), error) {
+	if rl.rate > 0 {
+		if err := rl.waitToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if rl.sem == nil {
+		return func() {}, nil
+	}
+
+	// A call rejected for concurrency gives its rate token back
+	if rl.failFast {
+		select {
+		case rl.sem <- struct{}{}:
+		default:
+			rl.returnToken()
+			return nil, &RateLimitError{FriendlyName: rl.name, Reason: "concurrency"}
+		}
+	} else {
+		select {
+		case rl.sem <- struct{}{}:
+		case <-ctx.Done():
+			rl.returnToken()
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() { <-rl.sem }, nil
+}
+
+// waitToken takes one token from the bucket, waiting for a refill when needed
+func (rl *rateLimiter) waitToken(ctx context.Context) error {
+	rl.mu.Lock()
+	now := rl.now()
+	rl.tokens = min(rl.burst, rl.tokens+now.Sub(rl.last).Seconds()*rl.rate)
+	rl.last = now
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		rl.mu.Unlock()
+		return nil
+	}
+	if rl.failFast {
+		rl.mu.Unlock()
+		return &RateLimitError{FriendlyName: rl.name, Reason: "rate"}
+	}
+
+	// Reserve the next token and wait until it becomes available
+	wait := time.Duration((1 - rl.tokens) / rl.rate * float64(time.Second))
+	rl.tokens--
+	rl.mu.Unlock()
+
+	if err := sleepContext(ctx, wait); err != nil {
+		// Give the reservation back
+		rl.returnToken()
+		return err
+	}
+	return nil
+}
+
+// returnToken gives back a token taken by waitToken for a call that was not made
+func (rl *rateLimiter) returnToken() {
+	if rl.rate <= 0 {
+		return
+	}
+	rl.mu.Lock()
+	rl.tokens = min(rl.burst, rl.tokens+1)
+	rl.mu.Unlock()
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRateLimiterFailFast verifies the token bucket rejects calls once the burst is used
+func TestRateLimiterFailFast(t *testing.T) {
+	rl := newRateLimiter("ratelimit-failfast", RateLimitConfig{
+		RequestsPerSecond: 1,
+		Burst:             2,
+		FailFast:          true,
+	})
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+	rl.last = now
+
+	for range 2 {
+		release, err := rl.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("Expected call within burst to be allowed: %v", err)
+		}
+		release()
+	}
+
+	_, err := rl.acquire(context.Background())
+	var rateErr *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rateErr) || rateErr.Reason != "rate" {
+		t.Fatalf("Expected rate *RateLimitError, got %v", err)
+	}
+
+	// One second later a single token has been refilled
+	now = now.Add(time.Second)
+	if _, err := rl.acquire(context.Background()); err != nil {
+		t.Fatalf("Expected refilled token to be available: %v", err)
+	}
+}
+
+// TestRateLimiterConcurrencyKeepsTokens verifies calls rejected for concurrency do not spend rate tokens
+func TestRateLimiterConcurrencyKeepsTokens(t *testing.T) {
+	rl := newRateLimiter("ratelimit-concurrency", RateLimitConfig{
+		RequestsPerSecond: 1,
+		Burst:             2,
+		MaxInFlight:       1,
+		FailFast:          true,
+	})
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+	rl.last = now
+
+	release, err := rl.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the first call to be allowed: %v", err)
+	}
+	for range 3 {
+		var rateErr *RateLimitError
+		if _, err := rl.acquire(context.Background()); !errors.As(err, &rateErr) || rateErr.Reason != "concurrency" {
+			t.Fatalf("Expected concurrency *RateLimitError, got %v", err)
+		}
+	}
+	release()
+	if _, err := rl.acquire(context.Background()); err != nil {
+		t.Errorf("Expected the remaining token to be available: %v", err)
+	}
+}
+
+// TestRateLimiterWaits verifies waiting callers are delayed rather than rejected
+func TestRateLimiterWaits(t *testing.T) {
+	rl := newRateLimiter("ratelimit-wait", RateLimitConfig{RequestsPerSecond: 20})
+
+	start := time.Now()
+	for range 3 {
+		release, err := rl.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("Expected waiting acquire to succeed: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected calls to be spaced by the rate limit, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := rl.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context cancellation while waiting, got %v", err)
+	}
+}
+
+// TestEndpointMaxInFlight verifies the concurrency cap is shared across users of a cached caller
+func TestEndpointMaxInFlight(t *testing.T) {
+	var inFlight, peak int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "ratelimit-inflight-test",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+		RateLimit:    &RateLimitConfig{MaxInFlight: 2},
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Go(func() {
+			e := endpoint
+			if _, err := e.Call(map[string]any{}); err != nil {
+				t.Errorf("Call failed: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if p := atomic.LoadInt32(&peak); p > 2 {
+		t.Errorf("Expected at most 2 concurrent calls, observed %d", p)
+	}
+}
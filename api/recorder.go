@@ -0,0 +1,546 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+)
+
+// RecorderMode selects whether a Recorder captures or serves back calls
+type RecorderMode string
+
+const (
+	// RecorderModeRecord makes real calls and captures them to the fixture file
+	RecorderModeRecord RecorderMode = "record"
+	// RecorderModeReplay serves calls from the fixture file
+	RecorderModeReplay RecorderMode = "replay"
+)
+
+// ErrUnrecordedCall is returned in strict replay mode for calls missing from the fixture
+var ErrUnrecordedCall = errors.New("unrecorded call")
+
+// UnrecordedCallError is the typed error returned in strict replay mode when no
+// recorded interaction matches a call. It matches ErrUnrecordedCall with errors.Is
+type UnrecordedCallError struct {
+	// FriendlyName is the name of the endpoint
+	FriendlyName string
+	// Method is the HTTP method or operation name of the call
+	Method string
+	// URL is the endpoint URL
+	URL string
+}
+
+// Error implements the error interface
+func (e *UnrecordedCallError) Error() string {
+	return fmt.Sprintf("no recorded interaction for endpoint '%s': %s %s", e.FriendlyName, e.Method, e.URL)
+}
+
+// Unwrap allows errors.Is(err, ErrUnrecordedCall)
+func (e *UnrecordedCallError) Unwrap() error {
+	return ErrUnrecordedCall
+}
+
+// RecordedBody is a request or response body stored in a fixture
+// Text holds UTF-8 content, Base64 binary content and JSON structured content.
+type RecordedBody struct {
+	// Kind is "bytes", "map", "graphql", "stream", "json" or "unrecorded"
+	Kind   string          `json:"kind"`
+	Text   string          `json:"text,omitempty"`
+	Base64 string          `json:"base64,omitempty"`
+	JSON   json.RawMessage `json:"json,omitempty"`
+}
+
+// RecordedRequest is the request side of a recorded interaction
+type RecordedRequest struct {
+	FriendlyName string       `json:"friendlyName"`
+	Type         EndpointType `json:"type"`
+	URL          string       `json:"url"`
+	Method       string       `json:"method,omitempty"`
+	// Headers are the call headers with secrets redacted (credentials added by an
+	// Authenticator are never recorded)
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    *RecordedBody     `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response side of a recorded interaction
+type RecordedResponse struct {
+	StatusCode int                 `json:"statusCode"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Trailers   map[string][]string `json:"trailers,omitempty"`
+	Body       *RecordedBody       `json:"body,omitempty"`
+	// GRPCCode is the gRPC status code name (informational, gRPC only)
+	GRPCCode string `json:"grpcCode,omitempty"`
+	// GRPCStatus is the google.rpc.Status in wire format, including details (gRPC only)
+	GRPCStatus []byte `json:"grpcStatus,omitempty"`
+}
+
+// Interaction is a recorded call
+type Interaction struct {
+	Request RecordedRequest `json:"request"`
+	// Response is nil if the call failed without a response (e.g. connection refused)
+	Response *RecordedResponse `json:"response,omitempty"`
+	// Error is the call error message, if any, with URLs redacted like Request.URL
+	Error string `json:"error,omitempty"`
+	// Duration is how long the recorded call took
+	Duration time.Duration `json:"duration"`
+}
+
+// MatchRule reports whether a recorded request matches an actual request
+type MatchRule func(recorded, actual *RecordedRequest) bool
+
+// MatchEndpoint matches the endpoint friendly name, type and URL
+func MatchEndpoint(recorded, actual *RecordedRequest) bool {
+	return recorded.FriendlyName == actual.FriendlyName && recorded.Type == actual.Type && recorded.URL == actual.URL
+}
+
+// MatchMethod matches the HTTP method or operation name
+func MatchMethod(recorded, actual *RecordedRequest) bool {
+	return recorded.Method == actual.Method
+}
+
+// MatchBody matches the request body (JSON bodies are compared in canonical form)
+func MatchBody(recorded, actual *RecordedRequest) bool {
+	if recorded.Body == nil || actual.Body == nil {
+		return recorded.Body == actual.Body
+	}
+	return recorded.Body.Kind == actual.Body.Kind &&
+		recorded.Body.Text == actual.Body.Text &&
+		recorded.Body.Base64 == actual.Body.Base64 &&
+		bytes.Equal(canonicalJSON(recorded.Body.JSON), canonicalJSON(actual.Body.JSON))
+}
+
+// MatchHeaders returns a rule matching the (redacted) values of the named headers
+func MatchHeaders(names ...string) MatchRule {
+	return func(recorded, actual *RecordedRequest) bool {
+		for _, name := range names {
+			if headerValue(recorded.Headers, name) != headerValue(actual.Headers, name) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// DefaultMatchRules match calls by endpoint, method and body
+var DefaultMatchRules = []MatchRule{MatchEndpoint, MatchMethod, MatchBody}
+
+// RecorderConfig configures a Recorder
+type RecorderConfig struct {
+	// Mode selects recording or replaying
+	Mode RecorderMode
+	// FixturePath is the fixture file, written on Stop when recording and read on start when replaying
+	FixturePath string
+	// Strict fails replayed calls without a matching interaction with *UnrecordedCallError
+	// If false, unmatched calls are made against the real endpoint
+	Strict bool
+	// MatchRules decide which recorded interaction serves a call; all must match
+	// If unset, DefaultMatchRules are used
+	MatchRules []MatchRule
+	// SimulateLatency delays replayed responses by the recorded duration
+	SimulateLatency bool
+	// RedactHeaders are additional header names redacted in the fixture, in requests,
+	// responses and trailers alike
+	// Authorization, cookies and API key headers are always redacted (see RedactHeaders)
+	RedactHeaders []string
+	// RedactQueryParams are additional query parameter names redacted in recorded URLs
+	// Userinfo and API key or token parameters are always redacted (see RedactURL)
+	RedactQueryParams []string
+}
+
+// Recorder captures calls made through Endpoint.Call and APICaller to a fixture
+// file, or serves them back from it. gRPC streams are not recorded.
+type Recorder struct {
+	config RecorderConfig
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+var (
+	activeRecorder      *Recorder
+	activeRecorderMutex sync.RWMutex
+)
+
+// StartRecorder starts recording or replaying calls of every endpoint
+// The caller cache is cleared so new callers use the recorder. Only one
+// recorder can be active at a time; call Stop when done.
+func StartRecorder(config RecorderConfig) (*Recorder, error) {
+	if config.FixturePath == "" {
+		return nil, errors.New("recorder fixture path is required")
+	}
+	if config.Mode != RecorderModeRecord && config.Mode != RecorderModeReplay {
+		return nil, fmt.Errorf("unsupported recorder mode: %s", config.Mode)
+	}
+	if len(config.MatchRules) == 0 {
+		config.MatchRules = DefaultMatchRules
+	}
+
+	recorder := &Recorder{config: config}
+	if config.Mode == RecorderModeReplay {
+		data, err := os.ReadFile(config.FixturePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		var fixture recorderFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture '%s': %w", config.FixturePath, err)
+		}
+		recorder.interactions = fixture.Interactions
+		recorder.used = make([]bool, len(fixture.Interactions))
+	}
+
+	activeRecorderMutex.Lock()
+	if activeRecorder != nil {
+		activeRecorderMutex.Unlock()
+		return nil, errors.New("a recorder is already active")
+	}
+	activeRecorder = recorder
+	activeRecorderMutex.Unlock()
+
+	ClearCallerCache()
+	return recorder, nil
+}
+
+// Stop deactivates the recorder and, when recording, writes the fixture file
+// The caller cache is cleared so later callers make real calls again.
+func (r *Recorder) Stop() error {
+	activeRecorderMutex.Lock()
+	if activeRecorder == r {
+		activeRecorder = nil
+	}
+	activeRecorderMutex.Unlock()
+	ClearCallerCache()
+
+	if r.config.Mode != RecorderModeRecord {
+		return nil
+	}
+	data, err := json.MarshalIndent(recorderFixture{Interactions: r.Interactions()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(r.config.FixturePath, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// Interactions returns the recorded or loaded interactions
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.interactions)
+}
+
+// recorderFixture is the fixture file format
+type recorderFixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// currentRecorder returns the active recorder, if any
+func currentRecorder() *Recorder {
+	activeRecorderMutex.RLock()
+	defer activeRecorderMutex.RUnlock()
+	return activeRecorder
+}
+
+// call records or replays a call, using next for real calls
+func (r *Recorder) call(endpoint Endpoint, options *CallOptions, next func(*CallOptions) (*Response, error)) (*Response, error) {
+	request := r.recordRequest(endpoint, options)
+	if r.config.Mode == RecorderModeReplay {
+		return r.replay(endpoint, options, request, next)
+	}
+
+	start := time.Now()
+	response, err := next(options)
+	interaction := Interaction{
+		Request:  *request,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		interaction.Error = redactErrorText(err, r.config.RedactQueryParams...)
+	}
+	if response != nil {
+		interaction.Response = r.recordResponse(endpoint, response)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return response, err
+}
+
+// replay serves the first unused matching interaction, or the last matching one
+// once all have been used
+func (r *Recorder) replay(endpoint Endpoint, options *CallOptions, request *RecordedRequest, next func(*CallOptions) (*Response, error)) (*Response, error) {
+	r.mu.Lock()
+	match := -1
+	for i := range r.interactions {
+		if !r.matches(&r.interactions[i].Request, request) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match >= 0 {
+		r.used[match] = true
+	}
+	r.mu.Unlock()
+
+	if match < 0 {
+		if r.config.Strict {
+			return nil, &UnrecordedCallError{FriendlyName: endpoint.FriendlyName, Method: options.Method, URL: RedactURL(callURL(endpoint, options), r.config.RedactQueryParams...)}
+		}
+		return next(options)
+	}
+
+	interaction := r.interactions[match]
+	if r.config.SimulateLatency && interaction.Duration > 0 {
+		if err := sleepContext(options.Context, interaction.Duration); err != nil {
+			return nil, err
+		}
+	}
+	return replayResponse(endpoint, options, &interaction)
+}
+
+// matches reports whether recorded matches actual under all match rules
+func (r *Recorder) matches(recorded, actual *RecordedRequest) bool {
+	for _, rule := range r.config.MatchRules {
+		if !rule(recorded, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// recordRequest captures the request side of a call
+func (r *Recorder) recordRequest(endpoint Endpoint, options *CallOptions) *RecordedRequest {
+	request := &RecordedRequest{
+		FriendlyName: endpoint.FriendlyName,
+		Type:         endpoint.Type,
+		URL:          RedactURL(callURL(endpoint, options), r.config.RedactQueryParams...),
+		Method:       options.Method,
+		Body:         recordRequestBody(options.Body),
+	}
+	if len(options.Headers) > 0 {
+		request.Headers = RedactHeaders(options.Headers, r.config.RedactHeaders...)
+	}
+	return request
+}
+
+// recordRequestBody captures a request body; readers cannot be captured without consuming them
+func recordRequestBody(body any) *RecordedBody {
+	switch v := body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return recordBytes("bytes", v)
+	case string:
+		return recordBytes("bytes", []byte(v))
+	case io.Reader:
+		return &RecordedBody{Kind: "unrecorded", Text: fmt.Sprintf("%T", body)}
+	case proto.Message:
+		data, err := protojson.Marshal(v)
+		if err != nil {
+			return &RecordedBody{Kind: "unrecorded", Text: fmt.Sprintf("%T", body)}
+		}
+		return &RecordedBody{Kind: "json", JSON: canonicalJSON(data)}
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return &RecordedBody{Kind: "unrecorded", Text: fmt.Sprintf("%T", body)}
+		}
+		return &RecordedBody{Kind: "json", JSON: canonicalJSON(data)}
+	}
+}
+
+// recordResponse captures a response; streamed bodies are read fully and replaced
+// Secret headers and trailers are redacted in the capture only.
+func (r *Recorder) recordResponse(endpoint Endpoint, response *Response) *RecordedResponse {
+	recorded := &RecordedResponse{
+		StatusCode: response.StatusCode,
+		Headers:    redactHeaderValues(response.Headers, r.config.RedactHeaders),
+		Trailers:   redactHeaderValues(response.Trailers, r.config.RedactHeaders),
+	}
+	if response.GRPCStatus != nil {
+		recorded.GRPCCode = response.GRPCStatus.Code().String()
+		recorded.GRPCStatus, _ = proto.Marshal(response.GRPCStatus.Proto())
+	}
+
+	switch body := response.Body.(type) {
+	case nil:
+	case []byte:
+		recorded.Body = recordBytes("bytes", body)
+	case *streamingBody:
+		data, err := io.ReadAll(body)
+		body.Close()
+		// Hand the caller an equivalent stream over the captured data
+		replacement := newStreamingBody(endpoint.FriendlyName, io.NopCloser(bytes.NewReader(data)), int64(len(data)), 0)
+		replacement.onClose = body.onClose
+		body.onClose = nil
+		response.Body = replacement
+		if err != nil {
+			response.Error = err
+		}
+		recorded.Body = recordBytes("stream", data)
+	case map[string]any:
+		data, _ := json.Marshal(body)
+		recorded.Body = &RecordedBody{Kind: "map", JSON: data}
+	case *GraphQLResponse:
+		data, _ := json.Marshal(body)
+		recorded.Body = &RecordedBody{Kind: "graphql", JSON: data}
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			recorded.Body = &RecordedBody{Kind: "unrecorded", Text: fmt.Sprintf("%T", body)}
+		} else {
+			recorded.Body = &RecordedBody{Kind: "json", JSON: data}
+		}
+	}
+	return recorded
+}
+
+// replayResponse rebuilds the response and error of a recorded interaction
+func replayResponse(endpoint Endpoint, options *CallOptions, interaction *Interaction) (*Response, error) {
+	recorded := interaction.Response
+	if recorded == nil {
+		return nil, errors.New(interaction.Error)
+	}
+
+	response := &Response{
+		StatusCode: recorded.StatusCode,
+		Headers:    cloneHeaderMap(recorded.Headers),
+		Trailers:   cloneHeaderMap(recorded.Trailers),
+	}
+	if recorded.GRPCCode != "" {
+		// An OK status has an empty wire format
+		var statusProto spb.Status
+		if err := proto.Unmarshal(recorded.GRPCStatus, &statusProto); err == nil {
+			response.GRPCStatus = status.FromProto(&statusProto)
+		}
+	}
+
+	var raw []byte
+	if recorded.Body != nil {
+		switch recorded.Body.Kind {
+		case "bytes", "stream":
+			raw = recorded.Body.bytes()
+			if options.Stream && recorded.Body.Kind == "stream" {
+				response.Body = newStreamingBody(endpoint.FriendlyName, io.NopCloser(bytes.NewReader(raw)), int64(len(raw)), endpoint.MaxResponseBytes)
+			} else {
+				response.Body = raw
+			}
+		case "map":
+			var body map[string]any
+			json.Unmarshal(recorded.Body.JSON, &body)
+			response.Body = body
+		case "graphql":
+			var body GraphQLResponse
+			json.Unmarshal(recorded.Body.JSON, &body)
+			response.Body = &body
+		case "json":
+			var body any
+			json.Unmarshal(recorded.Body.JSON, &body)
+			response.Body = body
+		}
+	}
+
+	if interaction.Error == "" {
+		return response, nil
+	}
+
+	// Restore the typed errors callers inspect
+	var err error
+	if response.GRPCStatus != nil && response.GRPCStatus.Code() != codes.OK {
+		err = response.GRPCStatus.Err()
+	} else if graphQLResponse, ok := response.Body.(*GraphQLResponse); ok && len(graphQLResponse.Errors) > 0 {
+		err = &GraphQLError{FriendlyName: endpoint.FriendlyName, StatusCode: response.StatusCode, Errors: graphQLResponse.Errors}
+	} else if fault := soapFaultFromReplay(endpoint, raw, response.StatusCode); fault != nil {
+		err = fault
+	} else {
+		err = errors.New(interaction.Error)
+	}
+	response.Error = err
+	return response, err
+}
+
+// soapFaultFromReplay parses a replayed SOAP fault body
+func soapFaultFromReplay(endpoint Endpoint, raw []byte, statusCode int) error {
+	if endpoint.Type != EndpointTypeSOAP || len(raw) == 0 {
+		return nil
+	}
+	if fault := parseSOAPFault(raw, statusCode); fault != nil {
+		return fault
+	}
+	return nil
+}
+
+// recordBytes stores data as text when it is valid UTF-8, otherwise as base64
+func recordBytes(kind string, data []byte) *RecordedBody {
+	if utf8.Valid(data) {
+		return &RecordedBody{Kind: kind, Text: string(data)}
+	}
+	return &RecordedBody{Kind: kind, Base64: base64.StdEncoding.EncodeToString(data)}
+}
+
+// bytes returns the recorded byte content
+func (b *RecordedBody) bytes() []byte {
+	if b.Base64 != "" {
+		data, _ := base64.StdEncoding.DecodeString(b.Base64)
+		return data
+	}
+	return []byte(b.Text)
+}
+
+// canonicalJSON re-encodes data with sorted object keys so equal bodies compare equal
+func canonicalJSON(data []byte) json.RawMessage {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return data
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return data
+	}
+	return canonical
+}
+
+// headerValue returns the value of name in headers, ignoring case
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if http.CanonicalHeaderKey(key) == http.CanonicalHeaderKey(name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// cloneHeaderMap deep copies a header or metadata map
+func cloneHeaderMap(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+	cloned := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		cloned[key] = slices.Clone(values)
+	}
+	return cloned
+}
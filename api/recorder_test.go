@@ -0,0 +1,264 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestRecordReplay verifies REST, SOAP and gRPC calls are served back from a fixture
+func TestRecordReplay(t *testing.T) {
+	var counter atomic.Int32
+	restServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret-cookie")
+		fmt.Fprintf(w, `{"count":%d}`, counter.Add(1))
+	}))
+	soapServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+			`<faultcode>soap:Client</faultcode><faultstring>Invalid order</faultstring></soap:Fault></soap:Body></soap:Envelope>`))
+	}))
+	grpcServer, port, err := startTestAuthServer(func(metadata.MD) {})
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+
+	restEndpoint := Endpoint{FriendlyName: "Replay REST", URL: restServer.URL, Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	missingEndpoint := Endpoint{FriendlyName: "Replay REST Missing", URL: restServer.URL + "/missing", Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	soapEndpoint := Endpoint{FriendlyName: "Replay SOAP", URL: soapServer.URL, Type: EndpointTypeSOAP, Timeout: 5 * time.Second}
+	grpcEndpoint := Endpoint{
+		FriendlyName: "Replay gRPC",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/meta.MetaService/Check",
+		Timeout:      10 * time.Second,
+		Config:       &APICallerConfig{InsecureSkipVerify: true},
+	}
+
+	restParams := map[string]any{
+		"method":  "POST",
+		"headers": map[string]string{"Authorization": "Bearer secret-token", "X-Trace": "t1"},
+		"body":    map[string]any{"b": 2, "a": 1},
+	}
+	soapParams := map[string]any{"method": "PlaceOrder", "body": map[string]any{"Status": "bogus"}}
+
+	// callAll makes the same sequence of calls and returns their results and errors
+	callAll := func() ([]map[string]any, []error) {
+		var results []map[string]any
+		var errs []error
+		for _, call := range []struct {
+			endpoint Endpoint
+			params   map[string]any
+		}{
+			{restEndpoint, restParams},
+			{restEndpoint, restParams},
+			{missingEndpoint, map[string]any{"method": "GET", "query": map[string]string{"token": "secret-query"}}},
+			{soapEndpoint, soapParams},
+			{grpcEndpoint, map[string]any{"body": map[string]any{"name": "ok"}}},
+			{grpcEndpoint, map[string]any{"body": map[string]any{"name": "missing"}}},
+		} {
+			result, err := call.endpoint.Call(call.params)
+			delete(result, "headers")
+			results = append(results, result)
+			errs = append(errs, err)
+		}
+		return results, errs
+	}
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	recorder, err := StartRecorder(RecorderConfig{Mode: RecorderModeRecord, FixturePath: fixture})
+	if err != nil {
+		t.Fatalf("Failed to start recorder: %v", err)
+	}
+	recorded, recordedErrs := callAll()
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("Failed to stop recorder: %v", err)
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("Expected the authorization header to be redacted in the fixture")
+	}
+	if strings.Contains(string(data), "secret-cookie") {
+		t.Error("Expected the Set-Cookie response header to be redacted in the fixture")
+	}
+	if strings.Contains(string(data), "secret-query") {
+		t.Error("Expected the token query parameter to be redacted in the fixture")
+	}
+
+	// Replay with every server stopped
+	restServer.Close()
+	soapServer.Close()
+	grpcServer.Stop()
+
+	recorder, err = StartRecorder(RecorderConfig{Mode: RecorderModeReplay, FixturePath: fixture, Strict: true})
+	if err != nil {
+		t.Fatalf("Failed to start replay: %v", err)
+	}
+	defer recorder.Stop()
+	replayed, replayedErrs := callAll()
+
+	for i := range recorded {
+		recordedJSON, _ := json.Marshal(recorded[i])
+		replayedJSON, _ := json.Marshal(replayed[i])
+		if string(recordedJSON) != string(replayedJSON) {
+			t.Errorf("Call %d: expected replayed result %s, got %s", i, recordedJSON, replayedJSON)
+		}
+		if (recordedErrs[i] == nil) != (replayedErrs[i] == nil) {
+			t.Errorf("Call %d: expected error %v, got %v", i, recordedErrs[i], replayedErrs[i])
+		}
+	}
+	if recorded[0]["body"].(map[string]any)["count"] == recorded[1]["body"].(map[string]any)["count"] {
+		t.Error("Expected repeated calls to be replayed in recorded order")
+	}
+
+	// Typed errors survive the round trip
+	var faultErr *SOAPFaultError
+	if !errors.As(replayedErrs[3], &faultErr) || faultErr.Reason != "Invalid order" {
+		t.Errorf("Expected replayed *SOAPFaultError, got %T: %v", replayedErrs[3], replayedErrs[3])
+	}
+	if status.Code(replayedErrs[5]) != codes.NotFound {
+		t.Errorf("Expected replayed NotFound status, got %v", replayedErrs[5])
+	}
+	if !reflect.DeepEqual(replayed[5]["errorDetails"], recorded[5]["errorDetails"]) {
+		t.Errorf("Expected replayed error details %v, got %v", recorded[5]["errorDetails"], replayed[5]["errorDetails"])
+	}
+
+	// Strict mode rejects calls that were never recorded
+	_, err = restEndpoint.Call(map[string]any{"method": "DELETE"})
+	if !errors.Is(err, ErrUnrecordedCall) {
+		t.Errorf("Expected ErrUnrecordedCall, got %v", err)
+	}
+}
+
+// TestReplayMatchRules verifies custom match rules and non-strict passthrough
+func TestReplayMatchRules(t *testing.T) {
+	var live atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		live.Add(1)
+		w.Write([]byte("live"))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{FriendlyName: "Replay Rules", URL: server.URL, Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	interactions := []Interaction{
+		{
+			Request: RecordedRequest{
+				FriendlyName: endpoint.FriendlyName,
+				Type:         endpoint.Type,
+				URL:          endpoint.URL,
+				Method:       "GET",
+				Headers:      map[string]string{"X-Tenant": "acme"},
+			},
+			Response: &RecordedResponse{StatusCode: http.StatusOK, Body: &RecordedBody{Kind: "bytes", Text: "recorded"}},
+		},
+	}
+	data, _ := json.Marshal(recorderFixture{Interactions: interactions})
+	if err := os.WriteFile(fixture, data, 0o600); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	recorder, err := StartRecorder(RecorderConfig{
+		Mode:        RecorderModeReplay,
+		FixturePath: fixture,
+		MatchRules:  []MatchRule{MatchEndpoint, MatchMethod, MatchHeaders("X-Tenant")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start replay: %v", err)
+	}
+	defer recorder.Stop()
+
+	if _, err := StartRecorder(RecorderConfig{Mode: RecorderModeReplay, FixturePath: fixture}); err == nil {
+		t.Error("Expected an error starting a second recorder")
+	}
+
+	result, err := endpoint.Call(map[string]any{"method": "GET", "headers": map[string]string{"x-tenant": "acme"}})
+	if err != nil || result["body"] != "recorded" {
+		t.Errorf("Expected recorded body, got %v (%v)", result["body"], err)
+	}
+	// The last match is reused once every match has been served
+	result, _ = endpoint.Call(map[string]any{"method": "GET", "headers": map[string]string{"X-Tenant": "acme"}})
+	if result["body"] != "recorded" {
+		t.Errorf("Expected recorded body on repeat, got %v", result["body"])
+	}
+	if live.Load() != 0 {
+		t.Errorf("Expected no live calls, got %d", live.Load())
+	}
+
+	// Unmatched calls pass through to the real endpoint when not strict
+	result, err = endpoint.Call(map[string]any{"method": "GET", "headers": map[string]string{"X-Tenant": "other"}})
+	if err != nil || result["body"] != "live" {
+		t.Errorf("Expected live body, got %v (%v)", result["body"], err)
+	}
+	if live.Load() != 1 {
+		t.Errorf("Expected 1 live call, got %d", live.Load())
+	}
+}
+
+// TestRecordRedactsErrors verifies URLs in recorded error text are redacted in the fixture and on replay
+func TestRecordRedactsErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	unreachable := "http://user:secret-password@" + listener.Addr().String()
+	listener.Close()
+
+	endpoint := Endpoint{FriendlyName: "Replay Unreachable", URL: unreachable, Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	defer RemoveCallerFromCache(endpoint, nil)
+	params := map[string]any{"query": map[string]string{"token": "secret-token", "session": "secret-session"}}
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	recorder, err := StartRecorder(RecorderConfig{Mode: RecorderModeRecord, FixturePath: fixture, RedactQueryParams: []string{"session"}})
+	if err != nil {
+		t.Fatalf("Failed to start recorder: %v", err)
+	}
+	if _, err := endpoint.Call(params); err == nil || !strings.Contains(err.Error(), "secret-token") {
+		t.Fatalf("Expected a transport error quoting the URL, got %v", err)
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("Failed to stop recorder: %v", err)
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	for _, secret := range []string{"secret-password", "secret-token", "secret-session"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %s to be redacted in the fixture, got %s", secret, data)
+		}
+	}
+
+	recorder, err = StartRecorder(RecorderConfig{Mode: RecorderModeReplay, FixturePath: fixture, Strict: true, RedactQueryParams: []string{"session"}})
+	if err != nil {
+		t.Fatalf("Failed to start replay: %v", err)
+	}
+	defer recorder.Stop()
+	if _, err := endpoint.Call(params); err == nil || strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), "token=[REDACTED]") {
+		t.Errorf("Expected the replayed error to be redacted, got %v", err)
+	}
+}
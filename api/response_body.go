@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxDrainBytes is how much of an unread streaming body is discarded on Close
+// so the connection can be reused; larger remainders close the connection instead
+const maxDrainBytes = 256 << 10
+
+// ErrResponseTooLarge is returned when a response exceeds Endpoint.MaxResponseBytes
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ResponseTooLargeError is the typed error returned when a response body exceeds
+// Endpoint.MaxResponseBytes. It matches ErrResponseTooLarge with errors.Is
+type ResponseTooLargeError struct {
+	// FriendlyName is the name of the endpoint
+	FriendlyName string
+	// Limit is the configured maximum response size in bytes
+	Limit int64
+	// ContentLength is the advertised response size, or -1 if unknown
+	ContentLength int64
+}
+
+// Error implements the error interface
+func (e *ResponseTooLargeError) Error() string {
+	if e.ContentLength >= 0 {
+		return fmt.Sprintf("response from endpoint '%s' is %d bytes, exceeds limit of %d bytes", e.FriendlyName, e.ContentLength, e.Limit)
+	}
+	return fmt.Sprintf("response from endpoint '%s' exceeds limit of %d bytes", e.FriendlyName, e.Limit)
+}
+
+// Unwrap allows errors.Is(err, ErrResponseTooLarge)
+func (e *ResponseTooLargeError) Unwrap() error {
+	return ErrResponseTooLarge
+}
+
+// readLimitedBody reads body fully, failing with *ResponseTooLargeError beyond limit
+// A limit of 0 or less reads without a limit
+func readLimitedBody(name string, body io.Reader, contentLength int64, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(body)
+	}
+	if contentLength > limit {
+		return nil, &ResponseTooLargeError{FriendlyName: name, Limit: limit, ContentLength: contentLength}
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return data, err
+	}
+	if int64(len(data)) > limit {
+		return nil, &ResponseTooLargeError{FriendlyName: name, Limit: limit, ContentLength: contentLength}
+	}
+	return data, nil
+}
+
+// streamingBody is the io.ReadCloser returned for streamed responses
+// It enforces the size limit while reading and, on Close, drains a small
+// remainder so the cached client's connection stays reusable.
+type streamingBody struct {
+	name          string
+	body          io.ReadCloser
+	contentLength int64
+	limit         int64
+	read          int64
+	exceeded      bool
+
+	closeOnce sync.Once
+	closeErr  error
+	onClose   func()
+}
+
+// newStreamingBody wraps body with the endpoint size limit
+func newStreamingBody(name string, body io.ReadCloser, contentLength int64, limit int64) *streamingBody {
+	return &streamingBody{
+		name:          name,
+		body:          body,
+		contentLength: contentLength,
+		limit:         limit,
+	}
+}
+
+// Read implements io.Reader
+func (b *streamingBody) Read(p []byte) (int, error) {
+	if b.limit > 0 {
+		remaining := b.limit - b.read
+		if remaining <= 0 {
+			// Probe for a byte beyond the limit before failing
+			var probe [1]byte
+			n, err := b.body.Read(probe[:])
+			if n > 0 {
+				b.exceeded = true
+				return 0, &ResponseTooLargeError{FriendlyName: b.name, Limit: b.limit, ContentLength: b.contentLength}
+			}
+			return 0, err
+		}
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+// Close drains a small unread remainder, closes the body and releases the call context
+func (b *streamingBody) Close() error {
+	b.closeOnce.Do(func() {
+		if !b.exceeded {
+			io.CopyN(io.Discard, b.body, maxDrainBytes)
+		}
+		b.closeErr = b.body.Close()
+		if b.onClose != nil {
+			b.onClose()
+		}
+	})
+	return b.closeErr
+}
+
+// releaseOnClose defers release until the streamed body is closed, after any
+// release registered earlier. It returns false (and does not keep release) if
+// response has no streaming body.
+func releaseOnClose(response *Response, release func()) bool {
+	if response == nil {
+		return false
+	}
+	body, ok := response.Body.(*streamingBody)
+	if !ok {
+		return false
+	}
+	previous := body.onClose
+	body.onClose = func() {
+		if previous != nil {
+			previous()
+		}
+		release()
+	}
+	return true
+}
@@ -0,0 +1,446 @@
+package api
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trimble-oss/tierceron-core/v2/trcshfs/trcshio"
+)
+
+// defaultResponseCacheBytes is the byte budget of a response cache without MaxBytes
+const defaultResponseCacheBytes = 32 << 20
+
+// defaultResponseCachePath is the MemFs directory of cached bodies without Path
+const defaultResponseCachePath = "apicache"
+
+// ResponseCacheConfig configures a ResponseCache
+type ResponseCacheConfig struct {
+	// MaxBytes is the budget for cached response bodies; the least recently used are evicted
+	// If set to 0 or unset, defaults to 32 MiB
+	MaxBytes int64
+	// MemFs optionally stores cached bodies in a memory filesystem such as trcshfs.TrcshMemFs
+	// If unset, bodies are kept in the cache itself
+	MemFs trcshio.MemoryFileSystem
+	// Path is the MemFs directory for cached bodies
+	// If unset, defaults to "apicache"
+	Path string
+}
+
+// ResponseCache is a private HTTP cache for GET calls of REST and form-urlencoded endpoints
+// It honors Cache-Control, Expires and Vary, and revalidates stale entries with
+// If-None-Match and If-Modified-Since, serving the cached body on 304 Not Modified.
+// A ResponseCache is safe for concurrent use and may be shared by several endpoints.
+type ResponseCache struct {
+	config  ResponseCacheConfig
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int64
+}
+
+// cacheEntry is a stored response
+type cacheEntry struct {
+	key          string
+	statusCode   int
+	headers      http.Header
+	body         []byte
+	size         int64
+	expires      time.Time
+	noCache      bool
+	etag         string
+	lastModified string
+	vary         map[string]string
+}
+
+// NewResponseCache creates a response cache with config
+func NewResponseCache(config ResponseCacheConfig) *ResponseCache {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultResponseCacheBytes
+	}
+	if config.Path == "" {
+		config.Path = defaultResponseCachePath
+	}
+	return &ResponseCache{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Len returns the number of cached responses
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Size returns the total size of the cached bodies in bytes
+func (c *ResponseCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Clear removes every cached response
+func (c *ResponseCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.removeLocked(c.lru.Front())
+	}
+}
+
+// cacheRequest is the cache state of a single call
+type cacheRequest struct {
+	cache *ResponseCache
+	key   string
+	// entry is the stored response being revalidated, if any
+	entry *cacheEntry
+	// store reports whether the response may be stored
+	store bool
+}
+
+// responseCacheRequest prepares the cache for a call, returning nil if the call bypasses the cache
+// Unsafe methods are not cached but invalidate the cached response for their URL.
+// GET calls with an Authorization, Cookie or API key header bypass the cache.
+func (e *Endpoint) responseCacheRequest(options *CallOptions) *cacheRequest {
+	if e.ResponseCache == nil || options.Stream || (e.Type != EndpointTypeREST && e.Type != EndpointTypeFormURLEncoded) {
+		return nil
+	}
+	request := &cacheRequest{cache: e.ResponseCache, key: e.FriendlyName + " " + callURL(*e, options)}
+	switch e.effectiveMethod(options.Method) {
+	case http.MethodGet:
+	case http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	default:
+		return request
+	}
+
+	// Responses to per-call credentials are private to that caller, so they are neither served nor stored
+	for name := range options.Headers {
+		if isSensitiveHeader(name, nil) {
+			return nil
+		}
+	}
+	// Callers sending their own conditional headers handle 304 themselves
+	if headerValue(options.Headers, "If-None-Match") != "" || headerValue(options.Headers, "If-Modified-Since") != "" {
+		return nil
+	}
+	directives := parseCacheControl(headerValue(options.Headers, "Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return nil
+	}
+	request.store = true
+	request.entry = request.cache.lookup(request.key, options.Headers)
+	if request.entry != nil {
+		if _, ok := directives["no-cache"]; ok {
+			request.entry.noCache = true
+		} else if maxAge, ok := directives["max-age"]; ok && maxAge == "0" {
+			request.entry.noCache = true
+		}
+	}
+	return request
+}
+
+// fresh returns the cached response if it can be served without contacting the server
+func (r *cacheRequest) fresh() *Response {
+	if r.entry == nil || r.entry.noCache || !time.Now().Before(r.entry.expires) {
+		return nil
+	}
+	response := r.cache.response(r.entry)
+	if response == nil {
+		// The body was lost, so there is nothing to revalidate either
+		r.entry = nil
+	}
+	return response
+}
+
+// conditionalHeaders returns headers with validators of the stale entry added
+// The stale body is loaded first so a 304 can be served even if the entry is
+// evicted meanwhile; without it no validators are sent. The caller's map is copied.
+func (r *cacheRequest) conditionalHeaders(headers map[string]string) map[string]string {
+	if r.entry == nil || (r.entry.etag == "" && r.entry.lastModified == "") {
+		return headers
+	}
+	if r.cache.config.MemFs != nil {
+		body, err := r.cache.readBody(r.key)
+		if err != nil {
+			r.cache.remove(r.key)
+			r.entry = nil
+			return headers
+		}
+		r.entry.body = body
+	}
+	conditional := make(map[string]string, len(headers)+2)
+	maps.Copy(conditional, headers)
+	if r.entry.etag != "" {
+		conditional["If-None-Match"] = r.entry.etag
+	}
+	if r.entry.lastModified != "" {
+		conditional["If-Modified-Since"] = r.entry.lastModified
+	}
+	return conditional
+}
+
+// complete stores or revalidates with the response of a successful call
+// It returns the response to report, which is the stale response refreshed
+// by the 304 headers after a revalidation, whether or not it is still stored.
+func (r *cacheRequest) complete(options *CallOptions, response *Response) *Response {
+	if response == nil {
+		return response
+	}
+	if !r.store {
+		// A successful unsafe request invalidates the stored response
+		r.cache.remove(r.key)
+		return response
+	}
+	if response.StatusCode == http.StatusNotModified && r.entry != nil {
+		headers := r.entry.headers
+		if updated := r.cache.revalidate(r.entry, response.Headers); updated != nil {
+			headers = updated.headers
+		} else {
+			mergeNotModified(headers, response.Headers)
+		}
+		return &Response{
+			StatusCode: r.entry.statusCode,
+			Body:       append([]byte(nil), r.entry.body...),
+			Headers:    cloneHeaderMap(headers),
+			Cached:     true,
+		}
+	}
+	if response.StatusCode == http.StatusOK {
+		if body, ok := response.Body.([]byte); ok {
+			r.cache.store(r.key, options.Headers, response, body)
+		}
+	}
+	return response
+}
+
+// lookup returns a copy of the entry for key if it matches the Vary request headers
+func (c *ResponseCache) lookup(key string, requestHeaders map[string]string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*cacheEntry)
+	for name, value := range entry.vary {
+		if headerValue(requestHeaders, name) != value {
+			return nil
+		}
+	}
+	c.lru.MoveToFront(element)
+	copied := *entry
+	copied.headers = http.Header(cloneHeaderMap(entry.headers))
+	return &copied
+}
+
+// store adds a 200 response to the cache if its headers allow it
+func (c *ResponseCache) store(key string, requestHeaders map[string]string, response *Response, body []byte) {
+	headers := http.Header(cloneHeaderMap(response.Headers))
+	entry := &cacheEntry{
+		key:          key,
+		statusCode:   response.StatusCode,
+		headers:      headers,
+		size:         int64(len(body)),
+		etag:         headers.Get("ETag"),
+		lastModified: headers.Get("Last-Modified"),
+	}
+	if !entry.updateFreshness(time.Now()) {
+		c.remove(key)
+		return
+	}
+	if vary := headers.Values("Vary"); len(vary) > 0 {
+		entry.vary = make(map[string]string)
+		for _, value := range vary {
+			for name := range strings.SplitSeq(value, ",") {
+				name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+				if name == "*" {
+					c.remove(key)
+					return
+				}
+				if name != "" {
+					entry.vary[name] = headerValue(requestHeaders, name)
+				}
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeLocked(element)
+	}
+	if entry.size > c.config.MaxBytes {
+		return
+	}
+	if c.config.MemFs != nil {
+		if err := c.writeBody(key, body); err != nil {
+			return
+		}
+	} else {
+		entry.body = body
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.size
+	for c.size > c.config.MaxBytes && c.lru.Len() > 1 {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// revalidate merges the headers of a 304 response into the stored entry
+// It returns nil if the entry was evicted meanwhile or is no longer cacheable.
+func (c *ResponseCache) revalidate(stale *cacheEntry, notModified map[string][]string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[stale.key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*cacheEntry)
+	mergeNotModified(entry.headers, notModified)
+	entry.etag = entry.headers.Get("ETag")
+	entry.lastModified = entry.headers.Get("Last-Modified")
+	if !entry.updateFreshness(time.Now()) {
+		c.removeLocked(element)
+		return nil
+	}
+	copied := *entry
+	copied.headers = http.Header(cloneHeaderMap(entry.headers))
+	return &copied
+}
+
+// mergeNotModified updates stored headers with those of a 304 response
+func mergeNotModified(headers http.Header, notModified map[string][]string) {
+	for name, values := range notModified {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			// Describe the empty 304 body, not the stored one
+		default:
+			headers[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+}
+
+// remove deletes the entry for key
+func (c *ResponseCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.removeLocked(element)
+	}
+}
+
+// removeLocked deletes an entry and its MemFs body; c.mu must be held
+func (c *ResponseCache) removeLocked(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+	if c.config.MemFs != nil {
+		c.config.MemFs.Remove(c.bodyPath(entry.key))
+	}
+}
+
+// response builds the Response served from entry
+// A body lost from MemFs evicts the entry and yields nil.
+func (c *ResponseCache) response(entry *cacheEntry) *Response {
+	body := entry.body
+	if c.config.MemFs != nil {
+		var err error
+		if body, err = c.readBody(entry.key); err != nil {
+			c.remove(entry.key)
+			return nil
+		}
+	}
+	return &Response{
+		StatusCode: entry.statusCode,
+		Body:       append([]byte(nil), body...),
+		Headers:    cloneHeaderMap(entry.headers),
+		Cached:     true,
+	}
+}
+
+// bodyPath returns the MemFs path of the body stored for key
+func (c *ResponseCache) bodyPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return path.Join(c.config.Path, hex.EncodeToString(sum[:]))
+}
+
+// writeBody stores a body in MemFs
+func (c *ResponseCache) writeBody(key string, body []byte) error {
+	file, err := c.config.MemFs.Create(c.bodyPath(key))
+	if err != nil {
+		return fmt.Errorf("failed to create cached body: %w", err)
+	}
+	if _, err := file.Write(body); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write cached body: %w", err)
+	}
+	return file.Close()
+}
+
+// readBody loads a body from MemFs
+func (c *ResponseCache) readBody(key string) ([]byte, error) {
+	file, err := c.config.MemFs.Open(c.bodyPath(key))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// updateFreshness sets the expiry from the entry headers (RFC 9111)
+// It returns false if the response must not be stored: Cache-Control no-store,
+// or no freshness lifetime and no validator to revalidate with.
+func (e *cacheEntry) updateFreshness(now time.Time) bool {
+	directives := parseCacheControl(strings.Join(e.headers.Values("Cache-Control"), ","))
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	_, e.noCache = directives["no-cache"]
+
+	var lifetime time.Duration
+	if maxAge, ok := directives["max-age"]; ok {
+		if seconds, err := strconv.ParseInt(maxAge, 10, 64); err == nil {
+			lifetime = time.Duration(seconds) * time.Second
+		}
+	} else if expires := e.headers.Get("Expires"); expires != "" {
+		if expiresAt, err := http.ParseTime(expires); err == nil {
+			// Measure against the server clock when it is known
+			date, err := http.ParseTime(e.headers.Get("Date"))
+			if err != nil {
+				date = now
+			}
+			lifetime = expiresAt.Sub(date)
+		}
+	}
+	if age, err := strconv.ParseInt(e.headers.Get("Age"), 10, 64); err == nil {
+		lifetime -= time.Duration(age) * time.Second
+	}
+	e.expires = now.Add(lifetime)
+	return lifetime > 0 || e.etag != "" || e.lastModified != ""
+}
+
+// parseCacheControl splits a Cache-Control header into lower-cased directives
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for directive := range strings.SplitSeq(value, ",") {
+		name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(argument, `"`)
+	}
+	return directives
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/trimble-oss/tierceron-core/v2/trcshfs"
+)
+
+// cachingServer serves versioned JSON with configurable caching headers and records conditional requests
+type cachingServer struct {
+	mu           sync.Mutex
+	version      int
+	cacheControl string
+	vary         string
+	requests     int
+	conditionals []string
+	// onRequest runs before each response, e.g. to evict entries mid-call
+	onRequest func()
+}
+
+func (s *cachingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.onRequest != nil {
+		s.onRequest()
+	}
+	if r.Method != http.MethodGet {
+		s.version++
+		return
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	s.conditionals = append(s.conditionals, r.Header.Get("If-None-Match"))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", s.cacheControl)
+	if s.vary != "" {
+		w.Header().Set("Vary", s.vary)
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	fmt.Fprintf(w, `{"version": %d, "language": %q}`, s.version, r.Header.Get("Accept-Language"))
+}
+
+// cacheEndpoint returns a REST endpoint using cache
+func cacheEndpoint(name string, url string, cache *ResponseCache) Endpoint {
+	return Endpoint{FriendlyName: name, URL: url, Type: EndpointTypeREST, Timeout: 5 * time.Second, ResponseCache: cache}
+}
+
+// TestResponseCacheFreshness verifies fresh responses are served without contacting the server
+func TestResponseCacheFreshness(t *testing.T) {
+	handler := &cachingServer{cacheControl: "max-age=60"}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cache := NewResponseCache(ResponseCacheConfig{})
+	endpoint := cacheEndpoint("Test Cache Fresh", server.URL, cache)
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	first, err := endpoint.Call(nil)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	second, err := endpoint.Call(nil)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if handler.requests != 1 || second["cached"] != true || first["cached"] != nil {
+		t.Errorf("Expected the second call to be served from cache, got %d requests", handler.requests)
+	}
+	if fmt.Sprint(second["body"]) != fmt.Sprint(first["body"]) {
+		t.Errorf("Expected the cached body %v, got %v", first["body"], second["body"])
+	}
+
+	// Request no-cache forces revalidation, and a successful POST invalidates the entry
+	endpoint.Call(map[string]any{"headers": map[string]string{"Cache-Control": "no-cache"}})
+	if handler.requests != 2 || handler.conditionals[1] != `"v0"` {
+		t.Errorf("Expected a conditional request, got %d requests %v", handler.requests, handler.conditionals)
+	}
+	endpoint.Call(map[string]any{"method": "POST"})
+	if cache.Len() != 0 {
+		t.Errorf("Expected POST to invalidate the cached response, got %d entries", cache.Len())
+	}
+	result, _ := endpoint.Call(nil)
+	if body, _ := result["body"].(map[string]any); body["version"] != 1.0 {
+		t.Errorf("Expected the new version after invalidation, got %v", result["body"])
+	}
+}
+
+// TestResponseCacheRevalidation verifies ETag revalidation and 304 handling
+func TestResponseCacheRevalidation(t *testing.T) {
+	handler := &cachingServer{cacheControl: "no-cache"}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	memFs := trcshfs.NewTrcshMemFs()
+	cache := NewResponseCache(ResponseCacheConfig{MemFs: memFs})
+	endpoint := cacheEndpoint("Test Cache Revalidate", server.URL, cache)
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	endpoint.Call(nil)
+	if files, _ := memFs.ReadDir("apicache"); len(files) != 1 {
+		t.Errorf("Expected the body stored in MemFs, got %d files", len(files))
+	}
+
+	result, err := CallTyped[any, map[string]any](t.Context(), &endpoint, nil, nil)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if handler.requests != 2 || handler.conditionals[1] != `"v0"` {
+		t.Fatalf("Expected a conditional request, got %v", handler.conditionals)
+	}
+	if !result.Cached || result.StatusCode != http.StatusOK || result.Body["version"] != 0.0 {
+		t.Errorf("Expected the cached body after 304, got %d %v", result.StatusCode, result.Body)
+	}
+
+	// A changed resource replaces the entry
+	handler.version = 5
+	fresh, _ := endpoint.Call(nil)
+	if fresh["cached"] != nil || fresh["body"].(map[string]any)["version"] != 5.0 {
+		t.Errorf("Expected the new version, got %v", fresh)
+	}
+
+	cache.Clear()
+	if files, _ := memFs.ReadDir("apicache"); len(files) != 0 || cache.Size() != 0 {
+		t.Errorf("Expected Clear to remove MemFs bodies, got %d files", len(files))
+	}
+
+	// An entry evicted while revalidating still serves its stale body on 304
+	endpoint.Call(nil)
+	handler.onRequest = cache.Clear
+	evicted, err := endpoint.Call(nil)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if evicted["cached"] != true || evicted["statusCode"] != http.StatusOK || evicted["body"].(map[string]any)["version"] != 5.0 {
+		t.Errorf("Expected the stale body after 304 of an evicted entry, got %v", evicted)
+	}
+}
+
+// TestResponseCacheStorage verifies no-store, Vary and the byte budget
+func TestResponseCacheStorage(t *testing.T) {
+	handler := &cachingServer{cacheControl: "no-store"}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cache := NewResponseCache(ResponseCacheConfig{MaxBytes: 80})
+	endpoint := cacheEndpoint("Test Cache Storage", server.URL+"/{item}", cache)
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	endpoint.Call(map[string]any{"path": map[string]string{"item": "a"}})
+	if cache.Len() != 0 {
+		t.Error("Expected no-store responses not to be cached")
+	}
+
+	// Entries vary by Accept-Language
+	handler.cacheControl, handler.vary = "max-age=60", "Accept-Language"
+	english := map[string]any{"path": map[string]string{"item": "a"}, "headers": map[string]string{"Accept-Language": "en"}}
+	german := map[string]any{"path": map[string]string{"item": "a"}, "headers": map[string]string{"Accept-Language": "de"}}
+	endpoint.Call(english)
+	if result, _ := endpoint.Call(german); result["cached"] != nil || !strings.Contains(fmt.Sprint(result["body"]), "de") {
+		t.Errorf("Expected a Vary mismatch to miss the cache, got %v", result)
+	}
+	if result, _ := endpoint.Call(german); result["cached"] != true {
+		t.Errorf("Expected a Vary match to hit the cache, got %v", result)
+	}
+
+	// Per-call credentials bypass the cache
+	requests := handler.requests
+	secret := map[string]any{"path": map[string]string{"item": "a"}, "headers": map[string]string{"Accept-Language": "de", "authorization": "Bearer other"}}
+	if result, _ := endpoint.Call(secret); result["cached"] != nil || handler.requests != requests+1 {
+		t.Errorf("Expected a call with credentials to bypass the cache, got %v", result)
+	}
+
+	// Each body is about 35 bytes, so only two fit in 80
+	for _, item := range []string{"b", "c"} {
+		endpoint.Call(map[string]any{"path": map[string]string{"item": item}})
+	}
+	if cache.Len() != 2 || cache.Size() > 80 {
+		t.Errorf("Expected 2 entries within budget, got %d entries of %d bytes", cache.Len(), cache.Size())
+	}
+	requests = handler.requests
+	if result, _ := endpoint.Call(map[string]any{"path": map[string]string{"item": "c"}}); result["cached"] != true || handler.requests != requests {
+		t.Error("Expected the most recent entry to survive eviction")
+	}
+}
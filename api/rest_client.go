@@ -3,8 +3,8 @@ package api
 import (
 	"bytes"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
@@ -15,9 +15,12 @@ import (
 
 // RESTClient implements the Client interface for REST APIs
 type RESTClient struct {
-	endpoint   Endpoint
-	httpClient *http.Client
-	config     *APICallerConfig
+	endpoint    Endpoint
+	httpClient  *http.Client
+	config      *APICallerConfig
+	certificate *clientCertificate
+	// authClient serves the requests of the Authenticator (nil without one)
+	authClient *http.Client
 }
 
 // NewRESTClient creates a new REST client
@@ -28,49 +31,52 @@ func NewRESTClient(endpoint Endpoint, config *APICallerConfig) (*RESTClient, err
 	}
 
 	// Create HTTP client with TLS configuration
-	tlsConfig := &tls.Config{
-		MinVersion:         tls.VersionTLS12,
-		InsecureSkipVerify: config.InsecureSkipVerify,
-	}
-
-	// Load CA certificate if provided
-	if len(config.CACertData) > 0 {
-		caCertPool := x509.NewCertPool()
-		if !caCertPool.AppendCertsFromPEM(config.CACertData) {
-			return nil, fmt.Errorf("failed to parse CA certificate")
-		}
-		tlsConfig.RootCAs = caCertPool
+	tlsConfig, certificate, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
 	}
+	client.certificate = certificate
 
-	// Load client certificate if provided
-	if len(config.TLSCertData) > 0 && len(config.TLSKeyData) > 0 {
-		cert, err := tls.X509KeyPair(config.TLSCertData, config.TLSKeyData)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load client certificate: %w", err)
-		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
+	transport, err := buildHTTPTransport(config, tlsConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	client.httpClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
+		Transport: transport,
 		// No timeout here - we use context timeouts per-request
 	}
 
+	client.authClient, err = newAuthHTTPClient(config, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	return client, nil
 }
 
 // Call makes a REST API call
 func (rc *RESTClient) Call(options *CallOptions) (*Response, error) {
 	if options.Method == "" {
-		options.Method = "GET"
+		if rc.endpoint.Type == EndpointTypeMultipart {
+			options.Method = "POST"
+		} else {
+			options.Method = "GET"
+		}
 	}
 
 	// Prepare request body
 	var bodyReader io.Reader
+	var contentType string
 	if options.Body != nil {
-		if rc.endpoint.Type == EndpointTypeFormURLEncoded {
+		if rc.endpoint.Type == EndpointTypeMultipart {
+			multipartReader, multipartType, err := encodeMultipartBody(options.Body)
+			if err != nil {
+				return nil, err
+			}
+			bodyReader = multipartReader
+			contentType = multipartType
+		} else if rc.endpoint.Type == EndpointTypeFormURLEncoded {
 			formReader, err := encodeFormBody(options.Body)
 			if err != nil {
 				return nil, err
@@ -96,8 +102,14 @@ func (rc *RESTClient) Call(options *CallOptions) (*Response, error) {
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(options.Context, options.Method, rc.endpoint.URL, bodyReader)
+	requestURL, err := resolveRequestURL(rc.endpoint.URL, options)
+	if err != nil {
+		closeBody(bodyReader)
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(options.Context, options.Method, requestURL, bodyReader)
 	if err != nil {
+		closeBody(bodyReader)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
@@ -108,6 +120,11 @@ func (rc *RESTClient) Call(options *CallOptions) (*Response, error) {
 		}
 	}
 
+	// Multipart bodies carry their boundary in the Content-Type
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
 	// Set default Content-Type if not specified and body is present
 	if bodyReader != nil && req.Header.Get("Content-Type") == "" {
 		if rc.endpoint.Type == EndpointTypeFormURLEncoded {
@@ -117,6 +134,12 @@ func (rc *RESTClient) Call(options *CallOptions) (*Response, error) {
 		}
 	}
 
+	// Add credentials
+	if err := authenticateHTTPRequest(rc.config.Authenticator, rc.endpoint.FriendlyName, rc.authClient, req); err != nil {
+		closeBody(req.Body)
+		return &Response{Error: err}, err
+	}
+
 	// Make the request
 	resp, err := rc.httpClient.Do(req)
 	if err != nil {
@@ -124,11 +147,38 @@ func (rc *RESTClient) Call(options *CallOptions) (*Response, error) {
 			Error: fmt.Errorf("request failed: %w", err),
 		}, err
 	}
+
+	// Hand successful streamed responses to the caller unread
+	if options.Stream && resp.StatusCode < 400 {
+		limit := rc.endpoint.MaxResponseBytes
+		if limit > 0 && resp.ContentLength > limit {
+			resp.Body.Close()
+			err := &ResponseTooLargeError{FriendlyName: rc.endpoint.FriendlyName, Limit: limit, ContentLength: resp.ContentLength}
+			return &Response{
+				StatusCode: resp.StatusCode,
+				Headers:    resp.Header,
+				Error:      err,
+			}, err
+		}
+		return &Response{
+			StatusCode: resp.StatusCode,
+			Body:       newStreamingBody(rc.endpoint.FriendlyName, resp.Body, resp.ContentLength, limit),
+			Headers:    resp.Header,
+		}, nil
+	}
 	defer resp.Body.Close()
 
 	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	body, err := readLimitedBody(rc.endpoint.FriendlyName, resp.Body, resp.ContentLength, rc.endpoint.MaxResponseBytes)
 	if err != nil {
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return &Response{
+				StatusCode: resp.StatusCode,
+				Headers:    resp.Header,
+				Error:      err,
+			}, err
+		}
 		return &Response{
 			StatusCode: resp.StatusCode,
 			Headers:    resp.Header,
@@ -143,6 +193,9 @@ func (rc *RESTClient) Call(options *CallOptions) (*Response, error) {
 	}
 
 	// Check for HTTP errors
+	if resp.StatusCode == http.StatusUnauthorized {
+		invalidateCredentials(rc.config.Authenticator)
+	}
 	if resp.StatusCode >= 400 {
 		response.Error = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 	}
@@ -150,6 +203,14 @@ func (rc *RESTClient) Call(options *CallOptions) (*Response, error) {
 	return response, response.Error
 }
 
+// closeBody closes a request body that will not be sent
+// Streamed bodies such as multipart uploads stop their encoder when closed.
+func closeBody(body io.Reader) {
+	if closer, ok := body.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
 func encodeFormBody(body any) (io.Reader, error) {
 	switch v := body.(type) {
 	case url.Values:
@@ -177,15 +238,30 @@ func encodeFormBody(body any) (io.Reader, error) {
 	case io.Reader:
 		return v, nil
 	default:
-		return nil, fmt.Errorf("unsupported form body type: %T", body)
+		// Structs are encoded field by field
+		values, err := formValues(body)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(values.Encode()), nil
 	}
 }
 
+// setClientCertificate swaps the client certificate and drops idle connections using the old one
+func (rc *RESTClient) setClientCertificate(cert *tls.Certificate) error {
+	rc.certificate.set(cert)
+	rc.httpClient.CloseIdleConnections()
+	return nil
+}
+
 // Close closes the REST client
 func (rc *RESTClient) Close() error {
 	// HTTP client doesn't need explicit closing
 	if rc.httpClient != nil {
 		rc.httpClient.CloseIdleConnections()
 	}
+	if rc.authClient != nil {
+		rc.authClient.CloseIdleConnections()
+	}
 	return nil
 }
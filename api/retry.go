@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAttempt describes a failed call attempt passed to a RetryPolicy
+type RetryAttempt struct {
+	// Attempt is the number of attempts made so far (1 for the initial call)
+	Attempt int
+	// Elapsed is the time since the first attempt started
+	Elapsed time.Duration
+	// Response is the response of the failed attempt (may be nil)
+	Response *Response
+	// Err is the error returned by the failed attempt
+	Err error
+}
+
+// RetryPolicy decides whether a failed call attempt should be retried
+type RetryPolicy interface {
+	// NextRetry returns the delay before the next attempt and whether a retry should happen
+	NextRetry(attempt RetryAttempt) (time.Duration, bool)
+}
+
+// BackoffRetryPolicy is a configurable RetryPolicy using exponential backoff
+type BackoffRetryPolicy struct {
+	// MaxRetries is the maximum number of retry attempts (0 disables retries)
+	MaxRetries int
+	// MaxElapsedTime stops retrying once the next attempt would start after this
+	// much time since the first attempt. If set to 0 or unset, no limit is applied
+	MaxElapsedTime time.Duration
+	// InitialBackoff is the delay before the first retry
+	// If set to 0 or unset, defaults to 1 second
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries, including delays from Retry-After
+	// If set to 0 or unset, the delay is not capped
+	MaxBackoff time.Duration
+	// Multiplier is the backoff growth factor between retries
+	// If set to 0 or unset, defaults to 2
+	Multiplier float64
+	// Jitter enables full jitter: each delay is chosen uniformly between 0 and the backoff
+	Jitter bool
+	// HonorRetryAfter uses the HTTP Retry-After response header as the delay when present
+	// The delay is still capped by MaxBackoff; set MaxBackoff or MaxElapsedTime to bound it
+	HonorRetryAfter bool
+	// RetryOnTimeout retries attempts that failed with a timeout
+	RetryOnTimeout bool
+	// RetryOnConnectionReset retries attempts that failed because the connection was reset or closed
+	RetryOnConnectionReset bool
+	// RetryStatusCodes lists explicit HTTP status codes to retry (e.g. 429, 503)
+	RetryStatusCodes []int
+	// RetryStatusRanges lists hundreds ranges of HTTP status codes to retry (e.g. 5 for 5xx)
+	RetryStatusRanges []int
+	// RetryGRPCCodes lists gRPC status codes to retry (e.g. codes.Unavailable)
+	RetryGRPCCodes []codes.Code
+}
+
+// DefaultRetryPolicy returns the policy matching the legacy Endpoint retry fields:
+// exponential backoff of 1s, 2s, 4s, etc. on timeouts, plus the RetryErrorRange
+// status range when set
+func DefaultRetryPolicy(e Endpoint) *BackoffRetryPolicy {
+	policy := &BackoffRetryPolicy{
+		MaxRetries:     max(e.MaxRetries, 0),
+		InitialBackoff: time.Second,
+		Multiplier:     2,
+		RetryOnTimeout: true,
+	}
+	if e.RetryErrorRange > 0 {
+		policy.RetryStatusRanges = []int{e.RetryErrorRange}
+	}
+	return policy
+}
+
+// NextRetry implements RetryPolicy
+func (p *BackoffRetryPolicy) NextRetry(attempt RetryAttempt) (time.Duration, bool) {
+	if attempt.Err == nil || attempt.Attempt > p.MaxRetries {
+		return 0, false
+	}
+	if !p.isRetryable(attempt) {
+		return 0, false
+	}
+
+	delay := p.backoff(attempt.Attempt)
+	if p.HonorRetryAfter && attempt.Response != nil {
+		if retryAfter, ok := parseRetryAfter(attempt.Response.Headers); ok {
+			delay = retryAfter
+			if p.MaxBackoff > 0 {
+				delay = min(delay, p.MaxBackoff)
+			}
+		}
+	}
+
+	if p.MaxElapsedTime > 0 && delay > p.MaxElapsedTime-attempt.Elapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+// isRetryable reports whether the failed attempt matches any retry rule
+func (p *BackoffRetryPolicy) isRetryable(attempt RetryAttempt) bool {
+	err := attempt.Err
+	if p.RetryOnTimeout {
+		if isTimeoutError(err) ||
+			(attempt.Response != nil && attempt.Response.Error != nil && isTimeoutError(attempt.Response.Error)) {
+			return true
+		}
+	}
+	if p.RetryOnConnectionReset && isConnectionResetError(err) {
+		return true
+	}
+	if len(p.RetryGRPCCodes) > 0 {
+		if s, ok := status.FromError(err); ok && slices.Contains(p.RetryGRPCCodes, s.Code()) {
+			return true
+		}
+	}
+	if attempt.Response != nil && attempt.Response.StatusCode > 0 {
+		statusCode := attempt.Response.StatusCode
+		if slices.Contains(p.RetryStatusCodes, statusCode) {
+			return true
+		}
+		for _, statusRange := range p.RetryStatusRanges {
+			if statusCode >= statusRange*100 && statusCode < (statusRange+1)*100 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backoff computes the delay before retry number n (1-based)
+func (p *BackoffRetryPolicy) backoff(n int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = time.Second
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	// Clamp before converting: an uncapped float delay overflows time.Duration
+	ceiling := float64(math.MaxInt64)
+	if p.MaxBackoff > 0 {
+		ceiling = float64(p.MaxBackoff)
+	}
+	delay := float64(initial)
+	for i := 1; i < n && delay < ceiling; i++ {
+		delay *= multiplier
+	}
+	backoff := time.Duration(math.MaxInt64)
+	if p.MaxBackoff > 0 {
+		backoff = p.MaxBackoff
+	}
+	if delay < ceiling {
+		backoff = time.Duration(delay)
+	}
+
+	if p.Jitter {
+		return time.Duration(rand.Int64N(int64(min(backoff, math.MaxInt64-1)) + 1))
+	}
+	return backoff
+}
+
+// parseRetryAfter reads the Retry-After header as delay-seconds or an HTTP date
+func parseRetryAfter(headers map[string][]string) (time.Duration, bool) {
+	value := http.Header(headers).Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return max(time.Until(when), 0), true
+	}
+	return 0, false
+}
+
+// isConnectionResetError reports whether err was caused by a reset or prematurely closed connection
+// io.EOF only counts when reported by the transport, not when returned bare, e.g. by a decoder.
+func isConnectionResetError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if !errors.Is(err, io.EOF) {
+		return false
+	}
+	var urlErr *url.Error
+	var opErr *net.OpError
+	return errors.As(err, &urlErr) || errors.As(err, &opErr)
+}
@@ -1,12 +1,22 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestRetryOnTimeout(t *testing.T) {
@@ -205,3 +215,166 @@ func TestZeroRetriesDisabled(t *testing.T) {
 
 	t.Logf("Correctly did not retry when MaxRetries=0 (attempts: %d)", attemptNum)
 }
+
+func TestRetryPolicyStatusCodes(t *testing.T) {
+	var attempts int32
+
+	// Create a server that is rate limited on the first request, then succeeds
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "retry-policy-status-test",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+		RetryPolicy: &BackoffRetryPolicy{
+			MaxRetries:       2,
+			InitialBackoff:   10 * time.Second,
+			HonorRetryAfter:  true,
+			RetryStatusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		},
+	}
+
+	start := time.Now()
+	result, err := endpoint.Call(map[string]any{})
+	if err != nil {
+		t.Fatalf("Expected success after retry, got error: %v", err)
+	}
+	if statusCode, _ := result["statusCode"].(int); statusCode != http.StatusOK {
+		t.Errorf("Expected status code 200, got: %v", result["statusCode"])
+	}
+	if attemptNum := atomic.LoadInt32(&attempts); attemptNum != 2 {
+		t.Errorf("Expected 2 attempts, got: %d", attemptNum)
+	}
+	// Retry-After of 0 must override the 10s initial backoff
+	if duration := time.Since(start); duration > 2*time.Second {
+		t.Errorf("Expected Retry-After to be honored, took: %v", duration)
+	}
+}
+
+func TestRetryPolicyMaxElapsedTime(t *testing.T) {
+	policy := &BackoffRetryPolicy{
+		MaxRetries:       10,
+		InitialBackoff:   time.Second,
+		MaxElapsedTime:   1500 * time.Millisecond,
+		RetryStatusCodes: []int{http.StatusServiceUnavailable},
+	}
+	response := &Response{StatusCode: http.StatusServiceUnavailable}
+	callErr := errors.New("HTTP 503")
+
+	if _, retry := policy.NextRetry(RetryAttempt{Attempt: 1, Response: response, Err: callErr}); !retry {
+		t.Error("Expected first retry within max elapsed time")
+	}
+	if _, retry := policy.NextRetry(RetryAttempt{Attempt: 2, Elapsed: time.Second, Response: response, Err: callErr}); retry {
+		t.Error("Expected no retry once max elapsed time would be exceeded")
+	}
+}
+
+// TestRetryPolicyRetryAfterCap verifies Retry-After delays are capped by MaxBackoff
+func TestRetryPolicyRetryAfterCap(t *testing.T) {
+	policy := &BackoffRetryPolicy{
+		MaxRetries:       1,
+		InitialBackoff:   time.Second,
+		HonorRetryAfter:  true,
+		RetryStatusCodes: []int{http.StatusTooManyRequests},
+	}
+	response := &Response{StatusCode: http.StatusTooManyRequests, Headers: map[string][]string{"Retry-After": {"86400"}}}
+	attempt := RetryAttempt{Attempt: 1, Response: response, Err: errors.New("HTTP 429")}
+
+	if delay, _ := policy.NextRetry(attempt); delay != 24*time.Hour {
+		t.Errorf("Expected the uncapped Retry-After delay, got %v", delay)
+	}
+	policy.MaxBackoff = 30 * time.Second
+	if delay, retry := policy.NextRetry(attempt); !retry || delay != 30*time.Second {
+		t.Errorf("Expected Retry-After capped to MaxBackoff, got %v (retry %t)", delay, retry)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &BackoffRetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+	}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("Retry %d: expected backoff %v, got %v", i+1, want, got)
+		}
+	}
+
+	policy.Jitter = true
+	for n := 1; n <= 5; n++ {
+		if got := policy.backoff(n); got < 0 || got > policy.MaxBackoff {
+			t.Errorf("Retry %d: jittered backoff %v out of range", n, got)
+		}
+	}
+
+	// Without MaxBackoff the delay saturates instead of overflowing
+	uncapped := &BackoffRetryPolicy{MaxRetries: 100, InitialBackoff: time.Second, MaxElapsedTime: time.Hour, RetryStatusCodes: []int{503}}
+	if got := uncapped.backoff(100); got != math.MaxInt64 {
+		t.Errorf("Expected the uncapped backoff to saturate, got %v", got)
+	}
+	uncapped.Jitter = true
+	if got := uncapped.backoff(100); got < 0 {
+		t.Errorf("Expected a non-negative jittered backoff, got %v", got)
+	}
+	uncapped.Jitter = false
+	if _, retry := uncapped.NextRetry(RetryAttempt{Attempt: 90, Elapsed: time.Minute, Response: &Response{StatusCode: 503}, Err: errors.New("HTTP 503")}); retry {
+		t.Error("Expected a saturated backoff to exceed MaxElapsedTime")
+	}
+}
+
+func TestRetryPolicyErrorClassification(t *testing.T) {
+	policy := &BackoffRetryPolicy{
+		MaxRetries:             1,
+		RetryOnConnectionReset: true,
+		RetryGRPCCodes:         []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+	}
+
+	cases := []struct {
+		name  string
+		err   error
+		retry bool
+	}{
+		{"connection reset", fmt.Errorf("request failed: %w", syscall.ECONNRESET), true},
+		{"unexpected EOF", fmt.Errorf("request failed: %w", io.ErrUnexpectedEOF), true},
+		{"transport EOF", &url.Error{Op: "Post", URL: "http://example.test", Err: io.EOF}, true},
+		{"bare EOF", fmt.Errorf("failed to decode response: %w", io.EOF), false},
+		{"grpc unavailable", status.Error(codes.Unavailable, "unavailable"), true},
+		{"grpc resource exhausted", status.Error(codes.ResourceExhausted, "quota"), true},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "bad"), false},
+		{"timeout not enabled", context.DeadlineExceeded, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, retry := policy.NextRetry(RetryAttempt{Attempt: 1, Err: tc.err}); retry != tc.retry {
+				t.Errorf("Expected retry=%t, got %t", tc.retry, retry)
+			}
+		})
+	}
+}
+
+func TestDefaultRetryPolicyMapsLegacyFields(t *testing.T) {
+	policy := DefaultRetryPolicy(Endpoint{MaxRetries: 2, RetryErrorRange: 5})
+	response := &Response{StatusCode: http.StatusBadGateway}
+	callErr := errors.New("HTTP 502")
+
+	delay, retry := policy.NextRetry(RetryAttempt{Attempt: 2, Response: response, Err: callErr})
+	if !retry || delay != 2*time.Second {
+		t.Errorf("Expected retry after 2s, got retry=%t delay=%v", retry, delay)
+	}
+	if _, retry := policy.NextRetry(RetryAttempt{Attempt: 3, Response: response, Err: callErr}); retry {
+		t.Error("Expected no retry beyond MaxRetries")
+	}
+	if _, retry := policy.NextRetry(RetryAttempt{Attempt: 1, Response: &Response{StatusCode: 404}, Err: callErr}); retry {
+		t.Error("Expected no retry outside RetryErrorRange")
+	}
+}
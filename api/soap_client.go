@@ -2,16 +2,37 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+// SOAPVersion selects the SOAP protocol version of an endpoint
+type SOAPVersion string
+
+const (
+	// SOAPVersion11 is SOAP 1.1 (text/xml with a SOAPAction header); the default
+	SOAPVersion11 SOAPVersion = "1.1"
+	// SOAPVersion12 is SOAP 1.2 (application/soap+xml with the action as a content type parameter)
+	SOAPVersion12 SOAPVersion = "1.2"
+)
+
+const (
+	// soap11EnvelopeNamespace is the SOAP 1.1 envelope namespace
+	soap11EnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	// soap12EnvelopeNamespace is the SOAP 1.2 envelope namespace
+	soap12EnvelopeNamespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
 // SOAPEnvelope represents a SOAP 1.1/1.2 envelope structure
+// It marshals with the SOAP 1.1 namespace; SOAP 1.2 endpoints rewrite it to the SOAP 1.2 namespace
 type SOAPEnvelope struct {
 	XMLName xml.Name    `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
 	Header  *SOAPHeader `xml:"Header,omitempty"`
@@ -37,13 +58,41 @@ type SOAPFault struct {
 	Detail string `xml:"detail,omitempty"`
 }
 
+// soap12Envelope is SOAPEnvelope marshaled with the SOAP 1.2 namespace
+type soap12Envelope struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+	Header  *SOAPHeader `xml:"Header,omitempty"`
+	Body    SOAPBody    `xml:"Body"`
+}
+
 // SOAPClient implements the Client interface for SOAP APIs
 type SOAPClient struct {
-	endpoint   Endpoint
-	httpClient *http.Client
-	config     *APICallerConfig
+	endpoint    Endpoint
+	httpClient  *http.Client
+	config      *APICallerConfig
+	certificate *clientCertificate
+	// authClient serves the requests of the Authenticator (nil without one)
+	authClient *http.Client
+	// wsdl is the parsed WSDL from Endpoint.WSDL or fetched once from Endpoint.WSDLUrl
+	wsdl      *WSDL
+	wsdlMutex sync.Mutex
+	// wsdlErr is the last fetch failure, returned until wsdlRetryAt
+	wsdlErr     error
+	wsdlRetryAt time.Time
+	wsdlBackoff time.Duration
+	// wsdlFetching is closed when the fetch in progress completes
+	wsdlFetching chan struct{}
 }
 
+// maxWSDLBytes caps the size of a fetched WSDL
+const maxWSDLBytes = 10 << 20
+
+// WSDL fetch failures are retried after a backoff doubling from minWSDLRetry to maxWSDLRetry
+const (
+	minWSDLRetry = time.Second
+	maxWSDLRetry = 5 * time.Minute
+)
+
 // NewSOAPClient creates a new SOAP client
 func NewSOAPClient(endpoint Endpoint, config *APICallerConfig) (*SOAPClient, error) {
 	client := &SOAPClient{
@@ -52,37 +101,133 @@ func NewSOAPClient(endpoint Endpoint, config *APICallerConfig) (*SOAPClient, err
 	}
 
 	// Create HTTP client with TLS configuration
-	tlsConfig := &tls.Config{
-		MinVersion:         tls.VersionTLS12,
-		InsecureSkipVerify: config.InsecureSkipVerify,
+	tlsConfig, certificate, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
 	}
+	client.certificate = certificate
 
-	// Load CA certificate if provided
-	if len(config.CACertData) > 0 {
-		caCertPool := x509.NewCertPool()
-		if !caCertPool.AppendCertsFromPEM(config.CACertData) {
-			return nil, fmt.Errorf("failed to parse CA certificate")
-		}
-		tlsConfig.RootCAs = caCertPool
+	transport, err := buildHTTPTransport(config, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	client.httpClient = &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
 	}
 
-	// Load client certificate if provided
-	if len(config.TLSCertData) > 0 && len(config.TLSKeyData) > 0 {
-		cert, err := tls.X509KeyPair(config.TLSCertData, config.TLSKeyData)
+	client.authClient, err = newAuthHTTPClient(config, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse a supplied WSDL up front so errors surface at creation
+	if len(endpoint.WSDL) > 0 {
+		wsdl, err := ParseWSDL(endpoint.WSDL)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			return nil, err
 		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
+		client.wsdl = wsdl
 	}
 
-	client.httpClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
-		Timeout: 30 * time.Second,
+	return client, nil
+}
+
+// GetWSDL returns the parsed WSDL for this endpoint
+// A WSDL supplied as bytes is used directly; otherwise it is fetched once from
+// Endpoint.WSDLUrl and cached. Returns nil without error if neither is configured.
+// A failed fetch is returned to every caller until it is retried after a backoff;
+// concurrent callers wait for the fetch in progress instead of starting their own.
+func (sc *SOAPClient) GetWSDL(ctx context.Context) (*WSDL, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sc.wsdlMutex.Lock()
+	for {
+		if sc.wsdl != nil || sc.endpoint.WSDLUrl == "" {
+			wsdl := sc.wsdl
+			sc.wsdlMutex.Unlock()
+			return wsdl, nil
+		}
+		if sc.wsdlErr != nil && time.Now().Before(sc.wsdlRetryAt) {
+			err := sc.wsdlErr
+			sc.wsdlMutex.Unlock()
+			return nil, err
+		}
+		fetching := sc.wsdlFetching
+		if fetching == nil {
+			break
+		}
+		sc.wsdlMutex.Unlock()
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		sc.wsdlMutex.Lock()
+	}
+	fetching := make(chan struct{})
+	sc.wsdlFetching = fetching
+	sc.wsdlMutex.Unlock()
+
+	wsdl, err := sc.fetchWSDL(ctx)
+
+	sc.wsdlMutex.Lock()
+	defer sc.wsdlMutex.Unlock()
+	sc.wsdlFetching = nil
+	close(fetching)
+	switch {
+	case err == nil:
+		sc.wsdl, sc.wsdlErr, sc.wsdlBackoff = wsdl, nil, 0
+	case ctx.Err() != nil:
+		// The caller gave up; the next caller fetches again
+	default:
+		sc.wsdlBackoff = min(max(2*sc.wsdlBackoff, minWSDLRetry), maxWSDLRetry)
+		sc.wsdlErr = err
+		sc.wsdlRetryAt = time.Now().Add(sc.wsdlBackoff)
 	}
+	return wsdl, err
+}
 
-	return client, nil
+// fetchWSDL downloads and parses the WSDL from Endpoint.WSDLUrl
+func (sc *SOAPClient) fetchWSDL(ctx context.Context) (*WSDL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sc.endpoint.WSDLUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WSDL request: %w", err)
+	}
+	if err := authenticateHTTPRequest(sc.config.Authenticator, sc.endpoint.FriendlyName, sc.authClient, req); err != nil {
+		return nil, err
+	}
+	resp, err := sc.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch WSDL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("failed to fetch WSDL: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWSDLBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WSDL: %w", err)
+	}
+	if len(data) > maxWSDLBytes {
+		return nil, fmt.Errorf("failed to read WSDL: larger than %d bytes", maxWSDLBytes)
+	}
+	return ParseWSDL(data)
+}
+
+// Operations returns the operations discovered from the endpoint WSDL
+func (sc *SOAPClient) Operations(ctx context.Context) ([]WSDLOperation, error) {
+	wsdl, err := sc.GetWSDL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if wsdl == nil {
+		return nil, errors.New("no WSDL configured for endpoint")
+	}
+	return wsdl.Operations, nil
 }
 
 // Call makes a SOAP API call
@@ -94,6 +239,15 @@ func (sc *SOAPClient) Call(options *CallOptions) (*Response, error) {
 	var bodyBytes []byte
 	var err error
 
+	// Resolve the operation from the WSDL when available
+	// An unavailable WSDL only fails map bodies that need its schema; other
+	// bodies fall back to the schema-less envelope.
+	var operation *WSDLOperation
+	wsdl, wsdlErr := sc.GetWSDL(options.Context)
+	if wsdl != nil {
+		operation, _ = wsdl.Operation(options.Method)
+	}
+
 	// Generate SOAP envelope automatically from Body parameter
 	switch v := options.Body.(type) {
 	case []byte:
@@ -103,20 +257,21 @@ func (sc *SOAPClient) Call(options *CallOptions) (*Response, error) {
 		// If body is a string (legacy support), use it directly
 		bodyBytes = []byte(v)
 	case map[string]any:
+		// Without an explicit SOAPAction, the action and element layout come from the WSDL
+		if wsdlErr != nil && !hasSOAPAction(options.Headers) {
+			return nil, wsdlErr
+		}
 		// Generate SOAP envelope from parameter map
-		bodyBytes, err = sc.generateSOAPEnvelope(options.Method, v)
+		bodyBytes, err = sc.generateSOAPEnvelope(options.Method, v, wsdl, operation)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate SOAP envelope: %w", err)
 		}
 	case *SOAPEnvelope:
 		// If body is already a SOAP envelope (legacy support), marshal it
-		bodyBytes, err = xml.MarshalIndent(v, "", "  ")
+		bodyBytes, err = sc.marshalEnvelope(v)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal SOAP envelope: %w", err)
 		}
-		// Add XML declaration
-		xmlDeclaration := []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-		bodyBytes = append(xmlDeclaration, bodyBytes...)
 	default:
 		// Wrap any other type in a SOAP envelope
 		soapEnvelope := &SOAPEnvelope{
@@ -124,13 +279,10 @@ func (sc *SOAPClient) Call(options *CallOptions) (*Response, error) {
 				Content: options.Body,
 			},
 		}
-		bodyBytes, err = xml.MarshalIndent(soapEnvelope, "", "  ")
+		bodyBytes, err = sc.marshalEnvelope(soapEnvelope)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal SOAP request: %w", err)
 		}
-		// Add XML declaration
-		xmlDeclaration := []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-		bodyBytes = append(xmlDeclaration, bodyBytes...)
 	}
 
 	// Create HTTP request
@@ -139,9 +291,6 @@ func (sc *SOAPClient) Call(options *CallOptions) (*Response, error) {
 		return nil, fmt.Errorf("failed to create SOAP request: %w", err)
 	}
 
-	// Set SOAP-specific headers
-	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
-
 	// Set SOAPAction header if provided in options
 	if options.Headers != nil {
 		for key, value := range options.Headers {
@@ -149,9 +298,35 @@ func (sc *SOAPClient) Call(options *CallOptions) (*Response, error) {
 		}
 	}
 
-	// Ensure SOAPAction header is set (required by SOAP 1.1)
-	if req.Header.Get("SOAPAction") == "" && options.Method != "" {
-		req.Header.Set("SOAPAction", fmt.Sprintf(`"%s"`, options.Method))
+	// Resolve the action: explicit header, then the WSDL binding, then the method name
+	action := strings.Trim(req.Header.Get("SOAPAction"), `"`)
+	if action == "" && operation != nil {
+		action = operation.action(sc.version())
+	}
+	if action == "" {
+		action = options.Method
+	}
+
+	// Set SOAP-specific headers
+	if sc.version() == SOAPVersion12 {
+		// SOAP 1.2 carries the action as a content type parameter
+		req.Header.Del("SOAPAction")
+		contentType := "application/soap+xml; charset=utf-8"
+		if action != "" {
+			contentType += fmt.Sprintf(`; action="%s"`, action)
+		}
+		req.Header.Set("Content-Type", contentType)
+	} else {
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		// Ensure SOAPAction header is set (required by SOAP 1.1)
+		if action != "" {
+			req.Header.Set("SOAPAction", fmt.Sprintf(`"%s"`, action))
+		}
+	}
+
+	// Add credentials
+	if err := authenticateHTTPRequest(sc.config.Authenticator, sc.endpoint.FriendlyName, sc.authClient, req); err != nil {
+		return &Response{Error: err}, err
 	}
 
 	// Make the request
@@ -179,16 +354,15 @@ func (sc *SOAPClient) Call(options *CallOptions) (*Response, error) {
 		Headers:    resp.Header,
 	}
 
-	// Check for SOAP faults
-	var soapResponse SOAPEnvelope
-	if err := xml.Unmarshal(respBody, &soapResponse); err == nil {
-		if soapResponse.Body.Fault != nil {
-			response.Error = fmt.Errorf("SOAP fault: [%s] %s - %s",
-				soapResponse.Body.Fault.Code,
-				soapResponse.Body.Fault.String,
-				soapResponse.Body.Fault.Detail)
-			return response, response.Error
-		}
+	// Discard cached credentials the server rejected
+	if resp.StatusCode == http.StatusUnauthorized {
+		invalidateCredentials(sc.config.Authenticator)
+	}
+
+	// Check for SOAP 1.1 and 1.2 faults
+	if faultErr := parseSOAPFault(respBody, resp.StatusCode); faultErr != nil {
+		response.Error = faultErr
+		return response, response.Error
 	}
 
 	// Check for HTTP errors
@@ -199,47 +373,98 @@ func (sc *SOAPClient) Call(options *CallOptions) (*Response, error) {
 	return response, response.Error
 }
 
+// setClientCertificate swaps the client certificate and drops idle connections using the old one
+func (sc *SOAPClient) setClientCertificate(cert *tls.Certificate) error {
+	sc.certificate.set(cert)
+	sc.httpClient.CloseIdleConnections()
+	return nil
+}
+
 // Close closes the SOAP client
 func (sc *SOAPClient) Close() error {
 	// HTTP client doesn't need explicit closing
 	if sc.httpClient != nil {
 		sc.httpClient.CloseIdleConnections()
 	}
+	if sc.authClient != nil {
+		sc.authClient.CloseIdleConnections()
+	}
 	return nil
 }
 
+// version returns the SOAP version of the endpoint, defaulting to SOAP 1.1
+func (sc *SOAPClient) version() SOAPVersion {
+	if sc.endpoint.SOAPVersion == SOAPVersion12 {
+		return SOAPVersion12
+	}
+	return SOAPVersion11
+}
+
+// marshalEnvelope marshals a SOAPEnvelope for the endpoint SOAP version with an XML declaration
+func (sc *SOAPClient) marshalEnvelope(envelope *SOAPEnvelope) ([]byte, error) {
+	var target any = envelope
+	if sc.version() == SOAPVersion12 {
+		target = &soap12Envelope{
+			Header: envelope.Header,
+			Body:   envelope.Body,
+		}
+	}
+
+	bodyBytes, err := xml.MarshalIndent(target, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Add XML declaration
+	xmlDeclaration := []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	return append(xmlDeclaration, bodyBytes...), nil
+}
+
 // generateSOAPEnvelope creates a SOAP envelope from a parameter map
-func (sc *SOAPClient) generateSOAPEnvelope(operationName string, params map[string]any) ([]byte, error) {
+// When the operation is known from the WSDL, the request element, namespace and
+// child element order and qualification follow the schema. Nested maps become
+// nested elements and slices become repeated elements.
+func (sc *SOAPClient) generateSOAPEnvelope(operationName string, params map[string]any, wsdl *WSDL, operation *WSDLOperation) ([]byte, error) {
 	// Determine namespace from WSDL URL or use default
 	namespace := "http://tempuri.org/"
-	if sc.endpoint.WSDLUrl != "" {
-		// Extract namespace from WSDL URL (simplified - could be enhanced with actual WSDL parsing)
-		// For now, use a heuristic based on the URL
+	if wsdl != nil && wsdl.TargetNamespace != "" {
+		namespace = wsdl.TargetNamespace
+	} else if sc.endpoint.WSDLUrl != "" {
+		// Heuristic based on the URL when the WSDL is not available
 		namespace = extractNamespaceFromURL(sc.endpoint.WSDLUrl)
 	}
 
 	// Build SOAP body content XML
-	var bodyContent bytes.Buffer
-	bodyContent.WriteString(fmt.Sprintf("<%s xmlns=\"%s\">", operationName, namespace))
-
-	// Add parameters as XML elements
-	for key, value := range params {
-		bodyContent.WriteString(fmt.Sprintf("<%s>%v</%s>", key, value, key))
+	bodyContent, err := encodeOperationBody(wsdl, operation, operationName, namespace, params)
+	if err != nil {
+		return nil, err
 	}
 
-	bodyContent.WriteString(fmt.Sprintf("</%s>", operationName))
-
 	// Create complete SOAP envelope
+	envelopeNamespace := soap11EnvelopeNamespace
+	if sc.version() == SOAPVersion12 {
+		envelopeNamespace = soap12EnvelopeNamespace
+	}
 	envelope := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
-<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+<soap:Envelope xmlns:soap="%s" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
   <soap:Body>
     %s
   </soap:Body>
-</soap:Envelope>`, bodyContent.String())
+</soap:Envelope>`, envelopeNamespace, bodyContent)
 
 	return []byte(envelope), nil
 }
 
+// hasSOAPAction reports whether headers set a non-empty SOAPAction
+func hasSOAPAction(headers map[string]string) bool {
+	for key, value := range headers {
+		if strings.EqualFold(key, "SOAPAction") && strings.Trim(value, `"`) != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // extractNamespaceFromURL attempts to extract the namespace from the WSDL URL
 func extractNamespaceFromURL(wsdlURL string) string {
 	// Remove ?wsdl or ?WSDL suffix
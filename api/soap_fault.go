@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// SOAPFaultError is the typed error returned when a SOAP response contains a Fault
+// It covers both the SOAP 1.1 (faultcode/faultstring) and SOAP 1.2 (Code/Reason) structures
+type SOAPFaultError struct {
+	// Version is the SOAP version of the fault envelope
+	Version SOAPVersion
+	// StatusCode is the HTTP status code of the response carrying the fault
+	StatusCode int
+	// Code is the fault code (faultcode for SOAP 1.1, Code/Value for SOAP 1.2)
+	Code string
+	// Subcodes are the nested Subcode/Value entries (SOAP 1.2 only)
+	Subcodes []string
+	// Reason is the human-readable fault text (faultstring for SOAP 1.1, first Reason/Text for SOAP 1.2)
+	Reason string
+	// Actor identifies who caused the fault (faultactor for SOAP 1.1, Role for SOAP 1.2)
+	Actor string
+	// Node is the SOAP node that generated the fault (SOAP 1.2 only)
+	Node string
+	// Detail is the raw inner XML of the fault detail element
+	Detail string
+	// DetailMap is the fault detail parsed into a map (nil if absent or not parseable)
+	DetailMap map[string]any
+}
+
+// Error implements the error interface
+func (e *SOAPFaultError) Error() string {
+	return fmt.Sprintf("SOAP fault: [%s] %s - %s", e.Code, e.Reason, strings.TrimSpace(e.Detail))
+}
+
+// soapFaultEnvelope matches a Fault in either SOAP envelope namespace
+type soapFaultEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Fault *struct {
+			// SOAP 1.1
+			FaultCode   string        `xml:"faultcode"`
+			FaultString string        `xml:"faultstring"`
+			FaultActor  string        `xml:"faultactor"`
+			Detail11    *soapInnerXML `xml:"detail"`
+			// SOAP 1.2
+			Code   soapFaultCode `xml:"Code"`
+			Reason struct {
+				Text []string `xml:"Text"`
+			} `xml:"Reason"`
+			Node     string        `xml:"Node"`
+			Role     string        `xml:"Role"`
+			Detail12 *soapInnerXML `xml:"Detail"`
+		} `xml:"Fault"`
+	} `xml:"Body"`
+}
+
+// soapFaultCode is the recursive SOAP 1.2 Code/Subcode structure
+type soapFaultCode struct {
+	Value   string         `xml:"Value"`
+	Subcode *soapFaultCode `xml:"Subcode"`
+}
+
+// soapInnerXML captures the raw content of an element
+type soapInnerXML struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+// parseSOAPFault returns the fault contained in a SOAP response, or nil if there is none
+func parseSOAPFault(data []byte, statusCode int) *SOAPFaultError {
+	var envelope soapFaultEnvelope
+	if err := xml.Unmarshal(data, &envelope); err != nil || envelope.Body.Fault == nil {
+		return nil
+	}
+	fault := envelope.Body.Fault
+
+	faultErr := &SOAPFaultError{
+		StatusCode: statusCode,
+	}
+	if envelope.XMLName.Space == soap12EnvelopeNamespace {
+		faultErr.Version = SOAPVersion12
+		faultErr.Code = strings.TrimSpace(fault.Code.Value)
+		for sub := fault.Code.Subcode; sub != nil; sub = sub.Subcode {
+			faultErr.Subcodes = append(faultErr.Subcodes, strings.TrimSpace(sub.Value))
+		}
+		if len(fault.Reason.Text) > 0 {
+			faultErr.Reason = strings.TrimSpace(fault.Reason.Text[0])
+		}
+		faultErr.Actor = strings.TrimSpace(fault.Role)
+		faultErr.Node = strings.TrimSpace(fault.Node)
+		if fault.Detail12 != nil {
+			faultErr.Detail = string(fault.Detail12.Inner)
+		}
+	} else {
+		faultErr.Version = SOAPVersion11
+		faultErr.Code = strings.TrimSpace(fault.FaultCode)
+		faultErr.Reason = strings.TrimSpace(fault.FaultString)
+		faultErr.Actor = strings.TrimSpace(fault.FaultActor)
+		if fault.Detail11 != nil {
+			faultErr.Detail = string(fault.Detail11.Inner)
+		}
+	}
+
+	if strings.TrimSpace(faultErr.Detail) != "" {
+		if detail, err := parseXMLToMap([]byte(faultErr.Detail)); err == nil && len(detail) > 0 {
+			faultErr.DetailMap = detail
+		}
+	}
+
+	return faultErr
+}
+
+// faultMap renders the fault for the Endpoint.Call result map
+func (e *SOAPFaultError) faultMap() map[string]any {
+	fault := map[string]any{
+		"version": string(e.Version),
+		"code":    e.Code,
+		"reason":  e.Reason,
+	}
+	if len(e.Subcodes) > 0 {
+		fault["subcodes"] = e.Subcodes
+	}
+	if e.Actor != "" {
+		fault["actor"] = e.Actor
+	}
+	if e.Node != "" {
+		fault["node"] = e.Node
+	}
+	if e.DetailMap != nil {
+		fault["detail"] = e.DetailMap
+	} else if detail := strings.TrimSpace(e.Detail); detail != "" {
+		fault["detail"] = detail
+	}
+	return fault
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSOAP12Request verifies SOAP 1.2 endpoints use the 1.2 envelope and content type
+func TestSOAP12Request(t *testing.T) {
+	var contentType, soapAction, requestBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		contentType = r.Header.Get("Content-Type")
+		soapAction = r.Header.Get("SOAPAction")
+		requestBody = string(data)
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		w.Write([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body>` +
+			`<GetOrdersResponse xmlns="http://example.com/">` +
+			`<Order><Id>1</Id><Line><Sku>A</Sku></Line><Line><Sku>B</Sku></Line></Order>` +
+			`<Order><Id>2</Id></Order>` +
+			`</GetOrdersResponse></env:Body></env:Envelope>`))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test SOAP 1.2",
+		URL:          server.URL,
+		Type:         EndpointTypeSOAP,
+		Timeout:      5 * time.Second,
+		SOAPVersion:  SOAPVersion12,
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	result, err := endpoint.Call(map[string]any{
+		"method":     "GetOrders",
+		"soapAction": "http://example.com/GetOrders",
+		"body":       map[string]any{"Status": "open"},
+	})
+	if err != nil {
+		t.Fatalf("SOAP 1.2 call failed: %v", err)
+	}
+
+	if contentType != `application/soap+xml; charset=utf-8; action="http://example.com/GetOrders"` {
+		t.Errorf("Unexpected content type: %s", contentType)
+	}
+	if soapAction != "" {
+		t.Errorf("Expected no SOAPAction header for SOAP 1.2, got %s", soapAction)
+	}
+	if !strings.Contains(requestBody, `xmlns:soap="http://www.w3.org/2003/05/soap-envelope"`) {
+		t.Errorf("Expected SOAP 1.2 envelope namespace, got %s", requestBody)
+	}
+
+	// Nested and repeated elements are preserved
+	body, _ := result["body"].(map[string]any)
+	orders, ok := body["Order"].([]any)
+	if !ok || len(orders) != 2 {
+		t.Fatalf("Expected 2 orders, got %v", body["Order"])
+	}
+	first, _ := orders[0].(map[string]any)
+	if first["Id"] != "1" {
+		t.Errorf("Expected first order Id 1, got %v", first["Id"])
+	}
+	if lines, ok := first["Line"].([]any); !ok || len(lines) != 2 {
+		t.Errorf("Expected 2 order lines, got %v", first["Line"])
+	}
+}
+
+// TestSOAPFaultErrors verifies SOAP 1.1 and 1.2 faults are returned as *SOAPFaultError
+func TestSOAPFaultErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		version  SOAPVersion
+		response string
+		code     string
+		reason   string
+		subcodes []string
+	}{
+		{
+			name:    "SOAP 1.1",
+			version: SOAPVersion11,
+			response: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+				`<faultcode>soap:Client</faultcode><faultstring>Invalid order</faultstring>` +
+				`<detail><OrderFault><Field>Status</Field></OrderFault></detail>` +
+				`</soap:Fault></soap:Body></soap:Envelope>`,
+			code:   "soap:Client",
+			reason: "Invalid order",
+		},
+		{
+			name:    "SOAP 1.2",
+			version: SOAPVersion12,
+			response: `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>` +
+				`<env:Code><env:Value>env:Sender</env:Value><env:Subcode><env:Value>m:InvalidOrder</env:Value></env:Subcode></env:Code>` +
+				`<env:Reason><env:Text xml:lang="en">Invalid order</env:Text></env:Reason>` +
+				`<env:Detail><OrderFault><Field>Status</Field></OrderFault></env:Detail>` +
+				`</env:Fault></env:Body></env:Envelope>`,
+			code:     "env:Sender",
+			reason:   "Invalid order",
+			subcodes: []string{"m:InvalidOrder"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			endpoint := Endpoint{
+				FriendlyName: "Test SOAP Fault " + tc.name,
+				URL:          server.URL,
+				Type:         EndpointTypeSOAP,
+				Timeout:      5 * time.Second,
+				SOAPVersion:  tc.version,
+			}
+			defer RemoveCallerFromCache(endpoint, nil)
+
+			result, err := endpoint.Call(map[string]any{
+				"method": "PlaceOrder",
+				"body":   map[string]any{"Status": "bogus"},
+			})
+
+			var faultErr *SOAPFaultError
+			if !errors.As(err, &faultErr) {
+				t.Fatalf("Expected *SOAPFaultError, got %T: %v", err, err)
+			}
+			if faultErr.Version != tc.version || faultErr.Code != tc.code || faultErr.Reason != tc.reason {
+				t.Errorf("Unexpected fault: %+v", faultErr)
+			}
+			if strings.Join(faultErr.Subcodes, ",") != strings.Join(tc.subcodes, ",") {
+				t.Errorf("Expected subcodes %v, got %v", tc.subcodes, faultErr.Subcodes)
+			}
+			orderFault, _ := faultErr.DetailMap["OrderFault"].(map[string]any)
+			if orderFault["Field"] != "Status" {
+				t.Errorf("Expected parsed fault detail, got %v", faultErr.DetailMap)
+			}
+
+			fault, _ := result["fault"].(map[string]any)
+			if fault["code"] != tc.code {
+				t.Errorf("Expected fault in result map, got %v", result["fault"])
+			}
+		})
+	}
+}
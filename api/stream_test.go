@@ -0,0 +1,230 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStreamingRESTBody verifies streamed bodies are decoded incrementally and the connection is reused
+func TestStreamingRESTBody(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := range 3 {
+			fmt.Fprintf(w, "{\"id\":%d}\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Streaming",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		Timeout:      5 * time.Second,
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	for range 2 {
+		result, err := endpoint.Call(map[string]any{"stream": true})
+		if err != nil {
+			t.Fatalf("Streaming call failed: %v", err)
+		}
+		body, ok := result["body"].(io.ReadCloser)
+		if !ok {
+			t.Fatalf("Expected io.ReadCloser body, got %T", result["body"])
+		}
+
+		var ids []int
+		for record, err := range DecodeNDJSON[struct{ ID int }](body) {
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			ids = append(ids, record.ID)
+		}
+		body.Close()
+		if fmt.Sprint(ids) != "[0 1 2]" {
+			t.Errorf("Expected ids [0 1 2], got %v", ids)
+		}
+	}
+
+	if connections.Load() != 1 {
+		t.Errorf("Expected the connection to be reused, got %d connections", connections.Load())
+	}
+
+	// An evicted caller stays open until the streamed body is closed
+	result, err := endpoint.Call(map[string]any{"stream": true})
+	if err != nil {
+		t.Fatalf("Streaming call failed: %v", err)
+	}
+	caller, _ := GetOrCreateAPICaller(endpoint, nil)
+	RemoveCallerFromCache(endpoint, nil)
+	caller.useMutex.Lock()
+	closed := caller.closed
+	caller.useMutex.Unlock()
+	if closed {
+		t.Error("Expected the caller to stay open while its body is streamed")
+	}
+	result["body"].(io.ReadCloser).Close()
+	if _, err := caller.CallContext(t.Context(), &CallOptions{}); !errors.Is(err, errCallerClosed) {
+		t.Errorf("Expected the caller to be closed with its body, got %v", err)
+	}
+}
+
+// TestMaxResponseBytes verifies buffered and streamed responses are limited
+func TestMaxResponseBytes(t *testing.T) {
+	payload := strings.Repeat("x", 1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", fmt.Sprint(len(payload)))
+		}
+		io.WriteString(w, payload)
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name   string
+		url    string
+		stream bool
+	}{
+		{"buffered with length", server.URL, false},
+		{"buffered chunked", server.URL + "?chunked=1", false},
+		{"streamed with length", server.URL, true},
+		{"streamed chunked", server.URL + "?chunked=1", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := Endpoint{
+				FriendlyName:     "Test Limit " + tc.name,
+				URL:              tc.url,
+				Type:             EndpointTypeREST,
+				MaxResponseBytes: 100,
+				CircuitBreaker:   &CircuitBreakerConfig{FailureThreshold: 1},
+			}
+			defer RemoveCallerFromCache(endpoint, nil)
+
+			result, err := endpoint.Call(map[string]any{"stream": tc.stream})
+			if err == nil {
+				body, ok := result["body"].(io.ReadCloser)
+				if !ok {
+					t.Fatalf("Expected error or streamed body, got %T", result["body"])
+				}
+				data, readErr := io.ReadAll(body)
+				body.Close()
+				if len(data) != 100 {
+					t.Errorf("Expected 100 bytes before the limit, got %d", len(data))
+				}
+				err = readErr
+			}
+
+			var tooLarge *ResponseTooLargeError
+			if !errors.As(err, &tooLarge) || !errors.Is(err, ErrResponseTooLarge) {
+				t.Fatalf("Expected *ResponseTooLargeError, got %T: %v", err, err)
+			}
+			if tooLarge.Limit != 100 {
+				t.Errorf("Expected limit 100, got %d", tooLarge.Limit)
+			}
+
+			caller, _ := GetOrCreateAPICaller(endpoint, &APICallerConfig{})
+			if state := caller.CircuitBreakerState(); state != CircuitClosed {
+				t.Errorf("Oversized responses should not open the circuit, got %v", state)
+			}
+		})
+	}
+}
+
+// TestStreamingHoldsConcurrencySlot verifies a streamed body keeps its MaxInFlight slot until closed
+func TestStreamingHoldsConcurrencySlot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data")
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Streaming Slot",
+		URL:          server.URL,
+		Type:         EndpointTypeREST,
+		RateLimit:    &RateLimitConfig{MaxInFlight: 1, FailFast: true},
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	result, err := endpoint.Call(map[string]any{"stream": true})
+	if err != nil {
+		t.Fatalf("Streaming call failed: %v", err)
+	}
+	body := result["body"].(io.ReadCloser)
+
+	if _, err := endpoint.Call(nil); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited while the stream is open, got %v", err)
+	}
+
+	body.Close()
+	if _, err := endpoint.Call(nil); err != nil {
+		t.Errorf("Expected call to succeed after closing the stream, got %v", err)
+	}
+}
+
+// TestDecodeSSE verifies server-sent event parsing
+func TestDecodeSSE(t *testing.T) {
+	stream := "\ufeff: comment\n" +
+		"event: update\n" +
+		"id: 1\n" +
+		"data: first\n" +
+		"data: second\r\n" +
+		"\n" +
+		"retry: 1500\n" +
+		"data:{\"n\":2}\n" +
+		"\n" +
+		"id: 3\n" +
+		"\n" +
+		"data: incomplete"
+
+	var events []SSEEvent
+	for event, err := range DecodeSSE(strings.NewReader(stream)) {
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %+v", len(events), events)
+	}
+	if events[0].Event != "update" || events[0].ID != "1" || events[0].Data != "first\nsecond" {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+	if events[1].Event != "message" || events[1].ID != "1" || events[1].Data != `{"n":2}` || events[1].Retry != 1500*time.Millisecond {
+		t.Errorf("Unexpected second event: %+v", events[1])
+	}
+}
+
+// TestDecodeNDJSONError verifies decoding stops at the first malformed record
+func TestDecodeNDJSONError(t *testing.T) {
+	var count int
+	var lastErr error
+	for _, err := range DecodeNDJSON[map[string]any](strings.NewReader("{\"a\":1}\nnot json\n{\"a\":3}\n")) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		count++
+	}
+	if count != 1 || lastErr == nil {
+		t.Errorf("Expected 1 record then an error, got %d records and %v", count, lastErr)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// clientCertificate holds the client certificate presented during TLS handshakes
+// It is read through tls.Config.GetClientCertificate so it can be swapped
+// on a live client without rebuilding its transport.
+type clientCertificate struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// get implements tls.Config.GetClientCertificate
+// An empty certificate tells the TLS stack to send none.
+func (c *clientCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return c.cert, nil
+}
+
+// set replaces the client certificate used by future handshakes
+func (c *clientCertificate) set(cert *tls.Certificate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = cert
+}
+
+// certificateRotator is implemented by clients whose client certificate can be hot-swapped
+type certificateRotator interface {
+	setClientCertificate(cert *tls.Certificate) error
+}
+
+// buildTLSConfig creates the client TLS configuration for config
+// The client certificate is served from the returned holder.
+func buildTLSConfig(config *APICallerConfig) (*tls.Config, *clientCertificate, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	// Load CA certificate if provided
+	if len(config.CACertData) > 0 {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(config.CACertData) {
+			return nil, nil, fmt.Errorf("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load client certificate if provided
+	certificate := &clientCertificate{}
+	if len(config.TLSCertData) > 0 && len(config.TLSKeyData) > 0 {
+		cert, err := tls.X509KeyPair(config.TLSCertData, config.TLSKeyData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		certificate.set(&cert)
+	}
+	tlsConfig.GetClientCertificate = certificate.get
+
+	return tlsConfig, certificate, nil
+}
+
+// UpdateClientCertificate hot-swaps the TLS client certificate of this caller
+// New connections present the new certificate; idle HTTP connections are closed
+// so they are re-established with it. Established gRPC connections keep the old
+// certificate until they reconnect.
+func (ac *APICaller) UpdateClientCertificate(certData, keyData []byte) error {
+	cert, err := tls.X509KeyPair(certData, keyData)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	rotator, ok := ac.client.(certificateRotator)
+	if !ok {
+		return errors.New("client does not support certificate rotation")
+	}
+	return rotator.setClientCertificate(&cert)
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// HTTP2Mode selects the HTTP protocol versions used by REST and SOAP clients
+type HTTP2Mode string
+
+const (
+	// HTTP2Default uses HTTP/1.1 only
+	HTTP2Default HTTP2Mode = ""
+	// HTTP2Attempt negotiates HTTP/2 over TLS and falls back to HTTP/1.1
+	HTTP2Attempt HTTP2Mode = "attempt"
+	// HTTP2Only uses HTTP/2 only, with prior knowledge (h2c) for http:// URLs
+	HTTP2Only HTTP2Mode = "only"
+)
+
+// proxyFunc returns the proxy selector for config, or nil to connect directly
+func proxyFunc(config *APICallerConfig) (func(*url.URL) (*url.URL, error), error) {
+	switch {
+	case config.ProxyURL != "":
+		if _, err := url.Parse(config.ProxyURL); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxyConfig := &httpproxy.Config{
+			HTTPProxy:  config.ProxyURL,
+			HTTPSProxy: config.ProxyURL,
+			NoProxy:    config.NoProxy,
+		}
+		return proxyConfig.ProxyFunc(), nil
+	case config.ProxyFromEnvironment:
+		return httpproxy.FromEnvironment().ProxyFunc(), nil
+	default:
+		return nil, nil
+	}
+}
+
+// newDialer returns the dial function for config
+// UnixSocket replaces the network address of every connection.
+func newDialer(config *APICallerConfig) func(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+	if config.UnixSocket == "" {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", config.UnixSocket)
+	}
+}
+
+// buildHTTPTransport creates the REST and SOAP transport for config
+func buildHTTPTransport(config *APICallerConfig, tlsConfig *tls.Config) (*http.Transport, error) {
+	proxy, err := proxyFunc(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:     tlsConfig,
+		DialContext:         newDialer(config),
+		MaxIdleConns:        config.MaxIdleConns,
+		MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     config.MaxConnsPerHost,
+		IdleConnTimeout:     config.IdleConnTimeout,
+	}
+	if proxy != nil {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	switch config.HTTP2 {
+	case HTTP2Default:
+	case HTTP2Attempt:
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+		transport.Protocols = protocols
+	case HTTP2Only:
+		protocols := new(http.Protocols)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = protocols
+	default:
+		return nil, fmt.Errorf("unsupported HTTP/2 mode: %s", config.HTTP2)
+	}
+	if config.HTTP2PingInterval > 0 || config.HTTP2PingTimeout > 0 {
+		transport.HTTP2 = &http.HTTP2Config{
+			SendPingTimeout: config.HTTP2PingInterval,
+			PingTimeout:     config.HTTP2PingTimeout,
+		}
+	}
+	return transport, nil
+}
+
+// grpcDialOptions returns the dialer, proxy, idle and keepalive options for config
+// A custom dialer disables the HTTPS_PROXY handling of grpc-go, so without ProxyURL
+// it resolves the environment proxy itself, as grpc-go does.
+func grpcDialOptions(config *APICallerConfig) ([]grpc.DialOption, error) {
+	proxy, err := proxyFunc(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []grpc.DialOption
+	dial := newDialer(config)
+	if proxy != nil || config.UnixSocket != "" || config.DialTimeout > 0 || config.KeepAlive != 0 {
+		scheme := "https"
+		if proxy == nil {
+			// grpc-go looks up every target as https
+			proxy = httpproxy.FromEnvironment().ProxyFunc()
+		} else if config.InsecureSkipVerify {
+			scheme = "http"
+		}
+		proxyTLS, err := proxyTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			if config.UnixSocket == "" {
+				proxyURL, err := proxy(&url.URL{Scheme: scheme, Host: address})
+				if err != nil {
+					return nil, err
+				}
+				if proxyURL != nil {
+					return dialConnectProxy(ctx, dial, proxyURL, proxyTLS, address)
+				}
+			}
+			return dial(ctx, "tcp", address)
+		}))
+	}
+	if config.IdleConnTimeout > 0 {
+		opts = append(opts, grpc.WithIdleTimeout(config.IdleConnTimeout))
+	}
+	if config.HTTP2PingInterval > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    config.HTTP2PingInterval,
+			Timeout: config.HTTP2PingTimeout,
+		}))
+	}
+	return opts, nil
+}
+
+// proxyTLSConfig returns the TLS configuration for https proxies, trusting CACertData when set
+func proxyTLSConfig(config *APICallerConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(config.CACertData) > 0 {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(config.CACertData) {
+			return nil, fmt.Errorf("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+	return tlsConfig, nil
+}
+
+// dialConnectProxy opens a tunnel to address through an HTTP CONNECT proxy
+// https proxies are connected to with a clone of proxyTLS.
+func dialConnectProxy(ctx context.Context, dial func(context.Context, string, string) (net.Conn, error), proxyURL *url.URL, proxyTLS *tls.Config, address string) (net.Conn, error) {
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	conn, err := dial(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy: %w", err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConfig := proxyTLS.Clone()
+		tlsConfig.ServerName = proxyURL.Hostname()
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	// Bound the handshake by the dial context
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send proxy CONNECT: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read proxy CONNECT response: %w", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s failed: %s", address, response.Status)
+	}
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read ahead
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read implements io.Reader
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// transportKey identifies the transport settings of config for the caller cache
+func transportKey(config *APICallerConfig) string {
+	return strings.Join([]string{
+		config.ProxyURL,
+		config.NoProxy,
+		fmt.Sprint(config.ProxyFromEnvironment),
+		config.UnixSocket,
+		config.DialTimeout.String(),
+		config.KeepAlive.String(),
+		fmt.Sprint(config.MaxIdleConns, config.MaxIdleConnsPerHost, config.MaxConnsPerHost),
+		config.IdleConnTimeout.String(),
+		string(config.HTTP2),
+		config.HTTP2PingInterval.String(),
+		config.HTTP2PingTimeout.String(),
+	}, "|")
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// TestRESTProxy verifies REST calls go through ProxyURL unless the host is in NoProxy
+func TestRESTProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		w.Write([]byte("via proxy " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	config := &APICallerConfig{ProxyURL: proxy.URL, NoProxy: "direct.test"}
+	endpoint := Endpoint{FriendlyName: "Test Proxy", URL: "http://backend.test/items", Type: EndpointTypeREST, Timeout: 5 * time.Second, Config: config}
+	defer RemoveCallerFromCache(endpoint, config)
+
+	result, err := endpoint.Call(nil)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if result["body"] != "via proxy http://backend.test/items" {
+		t.Errorf("Expected proxied response, got %v", result["body"])
+	}
+
+	direct := Endpoint{FriendlyName: "Test No Proxy", URL: "http://direct.test/items", Type: EndpointTypeREST, Timeout: 5 * time.Second, Config: config}
+	defer RemoveCallerFromCache(direct, config)
+	if _, err := direct.Call(nil); err == nil {
+		t.Error("Expected the direct call to an unresolvable host to fail")
+	}
+	if proxied.Load() != 1 {
+		t.Errorf("Expected 1 proxied request, got %d", proxied.Load())
+	}
+}
+
+// TestGRPCConnectProxy verifies gRPC connections are tunneled through an HTTP CONNECT proxy
+func TestGRPCConnectProxy(t *testing.T) {
+	var requestID atomic.Value
+	server, port, err := startTestAuthServer(func(md metadata.MD) {
+		requestID.Store(firstMetadataValue(md, "x-request-id"))
+	})
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	var tunnels atomic.Int32
+	proxyAddress := startConnectProxy(t, func(target string) string {
+		tunnels.Add(1)
+		// The proxy resolves the test host name to the local server
+		_, targetPort, _ := net.SplitHostPort(target)
+		return net.JoinHostPort("127.0.0.1", targetPort)
+	})
+
+	endpoint := Endpoint{
+		FriendlyName: "Test gRPC Proxy",
+		URL:          fmt.Sprintf("grpc.test:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/meta.MetaService/Check",
+		Timeout:      10 * time.Second,
+		Config: &APICallerConfig{
+			InsecureSkipVerify: true,
+			ProxyURL:           "http://" + proxyAddress,
+			DialTimeout:        5 * time.Second,
+			HTTP2PingInterval:  time.Minute,
+		},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	_, err = endpoint.Call(map[string]any{
+		"body":    map[string]any{"name": "ok"},
+		"headers": map[string]string{"x-request-id": "tunneled"},
+	})
+	if err != nil {
+		t.Fatalf("gRPC call failed: %v", err)
+	}
+	if tunnels.Load() == 0 {
+		t.Error("Expected the connection to be tunneled through the proxy")
+	}
+	if requestID.Load() != "tunneled" {
+		t.Errorf("Expected request to reach the server, got %v", requestID.Load())
+	}
+}
+
+// TestGRPCEnvironmentProxy verifies the custom dialer keeps honoring HTTPS_PROXY like grpc-go
+func TestGRPCEnvironmentProxy(t *testing.T) {
+	server, port, err := startTestAuthServer(func(metadata.MD) {})
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	var tunnels atomic.Int32
+	proxyAddress := startConnectProxy(t, func(target string) string {
+		tunnels.Add(1)
+		_, targetPort, _ := net.SplitHostPort(target)
+		return net.JoinHostPort("127.0.0.1", targetPort)
+	})
+	t.Setenv("HTTPS_PROXY", "http://"+proxyAddress)
+	t.Setenv("NO_PROXY", "")
+
+	endpoint := Endpoint{
+		FriendlyName: "Test gRPC Environment Proxy",
+		URL:          fmt.Sprintf("grpc.test:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/meta.MetaService/Check",
+		Timeout:      10 * time.Second,
+		Config:       &APICallerConfig{InsecureSkipVerify: true, DialTimeout: 5 * time.Second},
+	}
+	defer RemoveCallerFromCache(endpoint, endpoint.Config)
+
+	if _, err := endpoint.Call(map[string]any{"body": map[string]any{"name": "ok"}}); err != nil {
+		t.Fatalf("gRPC call failed: %v", err)
+	}
+	if tunnels.Load() == 0 {
+		t.Error("Expected the connection to be tunneled through the environment proxy")
+	}
+}
+
+// startConnectProxy starts a minimal HTTP CONNECT proxy, mapping targets with resolve
+func startConnectProxy(t *testing.T, resolve func(target string) string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				request, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || request.Method != http.MethodConnect {
+					return
+				}
+				upstream, err := net.Dial("tcp", resolve(request.Host))
+				if err != nil {
+					fmt.Fprint(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+				defer upstream.Close()
+				fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// TestUnixSocketAndHTTP2 verifies Unix socket dialing and HTTP/2 with prior knowledge
+func TestUnixSocketAndHTTP2(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix sockets not supported: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.Listener = listener
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	for _, tc := range []struct {
+		mode     HTTP2Mode
+		expected string
+	}{
+		{HTTP2Default, "HTTP/1.1"},
+		{HTTP2Only, "HTTP/2.0"},
+	} {
+		config := &APICallerConfig{UnixSocket: socket, HTTP2: tc.mode, MaxConnsPerHost: 4, IdleConnTimeout: time.Minute}
+		endpoint := Endpoint{FriendlyName: "Test Unix Socket", URL: "http://unix/proto", Type: EndpointTypeREST, Timeout: 5 * time.Second, Config: config}
+		result, err := endpoint.Call(nil)
+		RemoveCallerFromCache(endpoint, config)
+		if err != nil {
+			t.Fatalf("Call with HTTP/2 mode %q failed: %v", tc.mode, err)
+		}
+		if result["body"] != tc.expected {
+			t.Errorf("Expected %s with HTTP/2 mode %q, got %v", tc.expected, tc.mode, result["body"])
+		}
+	}
+}
+
+// TestTransportCacheKey verifies transport settings select distinct cached callers
+func TestTransportCacheKey(t *testing.T) {
+	endpoint := Endpoint{FriendlyName: "Test Transport Key", URL: "https://api.example.com", Type: EndpointTypeREST}
+	direct := &APICallerConfig{}
+	proxied := &APICallerConfig{ProxyURL: "http://proxy.example.com:3128"}
+	pooled := &APICallerConfig{MaxConnsPerHost: 8}
+	defer RemoveCallerFromCache(endpoint, direct)
+	defer RemoveCallerFromCache(endpoint, proxied)
+	defer RemoveCallerFromCache(endpoint, pooled)
+
+	keys := make(map[string]bool)
+	for _, config := range []*APICallerConfig{direct, proxied, pooled} {
+		caller, err := NewAPICaller(endpoint, config)
+		if err != nil {
+			t.Fatalf("Failed to create caller: %v", err)
+		}
+		keys[caller.GetCacheKey()] = true
+	}
+	if len(keys) != 3 {
+		t.Errorf("Expected 3 distinct cached callers, got %d", len(keys))
+	}
+
+	if _, err := NewAPICaller(endpoint, &APICallerConfig{HTTP2: "h3"}); err == nil {
+		t.Error("Expected an error for an unsupported HTTP/2 mode")
+	}
+}
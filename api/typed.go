@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// CallResult is the typed result of CallTyped
+type CallResult[Resp any] struct {
+	// StatusCode is the HTTP status code (closest HTTP equivalent for gRPC)
+	StatusCode int
+	// Headers are the response headers (headers and trailers for gRPC)
+	Headers map[string][]string
+	// Trailers are the gRPC response trailers (gRPC only)
+	Trailers map[string][]string
+	// GRPCStatus is the gRPC status of the call (gRPC only)
+	GRPCStatus *status.Status
+	// Body is the decoded response (zero value if the call failed)
+	Body Resp
+	// RawBody is the undecoded response body for REST and SOAP endpoints
+	// Useful for inspecting error payloads
+	RawBody []byte
+	// Attempts is the number of attempts made, including retries
+	// A response served from Endpoint.ResponseCache without contacting the server counts as one
+	Attempts int
+	// Cached is true if the response was served from Endpoint.ResponseCache
+	Cached bool
+}
+
+// CallTyped calls e with a typed request and decodes the response into Resp
+// The request is encoded according to the endpoint type:
+//   - REST: JSON
+//   - form-urlencoded: form values from the struct fields (`form` tag, then `json` tag, then field name)
+//   - SOAP: XML, wrapped in a SOAP envelope
+//   - gRPC: protobuf (proto.Message values are sent as-is, other values via their JSON form)
+//   - GraphQL: Req is a GraphQLRequest (or query string) and Resp receives the "data" member
+//
+// The response is decoded the same way: JSON for REST, the first element of the
+// SOAP Body for SOAP, and protobuf for gRPC. Resp may be []byte or string to
+// receive the raw body.
+//
+// params accepts the same keys as Endpoint.Call except "body", which is replaced by request,
+// and "stream", which is rejected since the body is always decoded.
+// A non-nil *CallResult is returned whenever a call was attempted, even if it failed.
+func CallTyped[Req, Resp any](ctx context.Context, e *Endpoint, request Req, params map[string]any) (*CallResult[Resp], error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if e == nil {
+		return nil, ErrEndpointNotFound
+	}
+	if stream, _ := params["stream"].(bool); stream {
+		return nil, errors.New("streamed responses are not supported by CallTyped")
+	}
+
+	callParams := make(map[string]any, len(params)+1)
+	for key, value := range params {
+		callParams[key] = value
+	}
+	body, err := encodeTypedRequest(e.Type, request)
+	if err != nil {
+		return nil, err
+	}
+	callParams["body"] = body
+
+	response, attempts, callErr := e.invoke(ctx, callParams)
+	if attempts == 0 {
+		// The caller could not be created
+		return nil, callErr
+	}
+
+	result := &CallResult[Resp]{Attempts: attempts}
+	if response != nil {
+		result.StatusCode = response.StatusCode
+		result.Headers = response.Headers
+		result.Trailers = response.Trailers
+		result.GRPCStatus = response.GRPCStatus
+		result.Cached = response.Cached
+		if raw, ok := response.Body.([]byte); ok {
+			result.RawBody = raw
+		}
+	}
+	if callErr != nil {
+		return result, callErr
+	}
+
+	if response != nil && response.Body != nil {
+		if err := decodeTypedResponse(e.Type, response.Body, &result.Body); err != nil {
+			if closer, ok := response.Body.(io.Closer); ok {
+				// An undecodable stream would otherwise stay open
+				closer.Close()
+			}
+			return result, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// encodeTypedRequest converts a typed request into a body the endpoint client accepts
+func encodeTypedRequest(endpointType EndpointType, request any) (any, error) {
+	switch endpointType {
+	case EndpointTypeFormURLEncoded:
+		switch request.(type) {
+		case url.Values, map[string]string, map[string][]string, map[string]any, []byte, string:
+			return request, nil
+		}
+		values, err := formValues(request)
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	case EndpointTypeREST:
+		switch request.(type) {
+		case []byte, string:
+			return request, nil
+		}
+		data, err := json.Marshal(request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		return data, nil
+	default:
+		// SOAP wraps the value in an envelope; gRPC converts it to the method input message
+		return request, nil
+	}
+}
+
+// decodeTypedResponse decodes a client response body into out
+func decodeTypedResponse(endpointType EndpointType, body any, out any) error {
+	switch target := out.(type) {
+	case *[]byte:
+		if raw, ok := body.([]byte); ok {
+			*target = raw
+			return nil
+		}
+	case *string:
+		if raw, ok := body.([]byte); ok {
+			*target = string(raw)
+			return nil
+		}
+	}
+
+	switch v := body.(type) {
+	case *GraphQLResponse:
+		if len(v.Data) == 0 || string(v.Data) == "null" {
+			return nil
+		}
+		return json.Unmarshal(v.Data, out)
+	case []byte:
+		if len(v) == 0 {
+			return nil
+		}
+		if endpointType == EndpointTypeSOAP {
+			return decodeSOAPBody(v, out)
+		}
+		return json.Unmarshal(v, out)
+	case map[string]any:
+		// gRPC responses are converted to maps using their protojson form
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if msg, ok := out.(proto.Message); ok {
+			return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+		}
+		// Resp may itself be a pointer to a proto.Message
+		if elem := reflect.ValueOf(out).Elem(); elem.Kind() == reflect.Pointer {
+			if _, ok := elem.Interface().(proto.Message); ok {
+				elem.Set(reflect.New(elem.Type().Elem()))
+				return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, elem.Interface().(proto.Message))
+			}
+		}
+		return json.Unmarshal(data, out)
+	default:
+		return fmt.Errorf("unsupported response body type: %T", body)
+	}
+}
+
+// decodeSOAPBody decodes the first element inside the SOAP Body into out
+func decodeSOAPBody(data []byte, out any) error {
+	var envelope struct {
+		Body soapInnerXML `xml:"Body"`
+	}
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to parse SOAP envelope: %w", err)
+	}
+	if len(strings.TrimSpace(string(envelope.Body.Inner))) == 0 {
+		return errors.New("SOAP body is empty")
+	}
+	return xml.Unmarshal(envelope.Body.Inner, out)
+}
+
+// formValues converts a struct (or pointer to struct) into form values
+// Field names come from the `form` tag, then the `json` tag, then the field name.
+// Fields tagged "-" and empty fields tagged omitempty are skipped; slices become repeated values.
+func formValues(v any) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported form body type: %T", v)
+	}
+
+	values := url.Values{}
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("form")
+		if !ok {
+			tag = field.Tag.Get("json")
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fv := rv.Field(i)
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		for fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Pointer {
+			continue
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			values.Set(name, string(fv.Bytes()))
+			continue
+		}
+		if fv.Kind() == reflect.Slice {
+			for j := range fv.Len() {
+				values.Add(name, fmt.Sprint(fv.Index(j).Interface()))
+			}
+			continue
+		}
+		values.Set(name, fmt.Sprint(fv.Interface()))
+	}
+	return values, nil
+}
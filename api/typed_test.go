@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type typedOrder struct {
+	ID    string   `json:"id"`
+	Qty   int      `json:"qty"`
+	Tags  []string `json:"tags,omitempty" form:"tag"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+type typedOrderResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// TestCallTypedREST verifies JSON encoding, decoding and the attempt count
+func TestCallTypedREST(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"id":"o-1","qty":2}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Order", "o-1")
+		w.Write([]byte(`{"id":"o-1","status":"accepted"}`))
+	}))
+	defer server.Close()
+
+	endpoint := &Endpoint{
+		FriendlyName:       "Typed REST",
+		URL:                server.URL,
+		Type:               EndpointTypeREST,
+		Timeout:            5 * time.Second,
+		RetryNonIdempotent: true,
+		RetryPolicy: &BackoffRetryPolicy{
+			MaxRetries:       1,
+			InitialBackoff:   time.Millisecond,
+			RetryStatusCodes: []int{http.StatusServiceUnavailable},
+		},
+	}
+	defer RemoveCallerFromCache(*endpoint, nil)
+
+	result, err := CallTyped[typedOrder, typedOrderResult](context.Background(), endpoint,
+		typedOrder{ID: "o-1", Qty: 2}, map[string]any{"method": "POST"})
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if result.StatusCode != http.StatusOK || result.Attempts != 2 {
+		t.Errorf("Expected status 200 after 2 attempts, got %d after %d", result.StatusCode, result.Attempts)
+	}
+	if result.Body.Status != "accepted" || result.Body.ID != "o-1" {
+		t.Errorf("Unexpected body: %+v", result.Body)
+	}
+	if len(result.Headers["X-Order"]) == 0 {
+		t.Errorf("Expected response headers, got %v", result.Headers)
+	}
+}
+
+// TestCallTypedError verifies failed calls return status and raw body without decoding,
+// and streamed calls are rejected
+func TestCallTypedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"bad qty"}`))
+	}))
+	defer server.Close()
+
+	endpoint := &Endpoint{FriendlyName: "Typed REST Error", URL: server.URL, Type: EndpointTypeREST}
+	defer RemoveCallerFromCache(*endpoint, nil)
+
+	result, err := CallTyped[typedOrder, typedOrderResult](context.Background(), endpoint, typedOrder{}, nil)
+	if err == nil {
+		t.Fatal("Expected error for HTTP 400")
+	}
+	if result == nil || result.StatusCode != http.StatusBadRequest || result.Attempts != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if string(result.RawBody) != `{"error":"bad qty"}` {
+		t.Errorf("Expected raw error body, got %s", result.RawBody)
+	}
+
+	// Streamed bodies cannot be decoded and are rejected before any call
+	if result, err := CallTyped[typedOrder, typedOrderResult](context.Background(), endpoint, typedOrder{}, map[string]any{"stream": true}); err == nil || result != nil {
+		t.Errorf("Expected a streamed call to be rejected, got %+v %v", result, err)
+	}
+}
+
+// TestCallTypedForm verifies struct fields are sent as form values
+func TestCallTypedForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		fmt.Fprintf(w, `{"id":%q,"status":%q}`, r.PostForm.Get("id"), strings.Join(r.PostForm["tag"], "+")+"/"+r.PostForm.Get("qty"))
+	}))
+	defer server.Close()
+
+	endpoint := &Endpoint{FriendlyName: "Typed Form", URL: server.URL, Type: EndpointTypeFormURLEncoded}
+	defer RemoveCallerFromCache(*endpoint, nil)
+
+	result, err := CallTyped[*typedOrder, typedOrderResult](context.Background(), endpoint,
+		&typedOrder{ID: "o-2", Qty: 3, Tags: []string{"a", "b"}}, map[string]any{"method": "POST"})
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if result.Body.ID != "o-2" || result.Body.Status != "a+b/3" {
+		t.Errorf("Unexpected body: %+v", result.Body)
+	}
+}
+
+// TestCallTypedSOAP verifies XML encoding in an envelope and decoding of the Body element
+func TestCallTypedSOAP(t *testing.T) {
+	type getPrice struct {
+		XMLName xml.Name `xml:"http://example.com/ GetPrice"`
+		Item    string   `xml:"Item"`
+	}
+	type getPriceResponse struct {
+		XMLName xml.Name `xml:"GetPriceResponse"`
+		Price   float64  `xml:"Price"`
+	}
+
+	var requestBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		requestBody = string(data)
+		w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<GetPriceResponse xmlns="http://example.com/"><Price>1.5</Price></GetPriceResponse>` +
+			`</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	endpoint := &Endpoint{FriendlyName: "Typed SOAP", URL: server.URL, Type: EndpointTypeSOAP}
+	defer RemoveCallerFromCache(*endpoint, nil)
+
+	result, err := CallTyped[getPrice, getPriceResponse](context.Background(), endpoint,
+		getPrice{Item: "Apples"}, map[string]any{"method": "GetPrice"})
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if !strings.Contains(requestBody, `<GetPrice xmlns="http://example.com/">`) || !strings.Contains(requestBody, `<Item>Apples</Item>`) {
+		t.Errorf("Unexpected request body: %s", requestBody)
+	}
+	if result.Body.Price != 1.5 {
+		t.Errorf("Expected price 1.5, got %+v", result.Body)
+	}
+}
+
+// TestCallTypedGRPC verifies proto.Message requests and typed gRPC responses
+func TestCallTypedGRPC(t *testing.T) {
+	server, port, err := startTestGRPCServer()
+	if err != nil {
+		t.Fatalf("Failed to start test gRPC server: %v", err)
+	}
+	defer server.GracefulStop()
+
+	endpoint := &Endpoint{
+		FriendlyName: "Typed gRPC",
+		URL:          fmt.Sprintf("localhost:%s", port),
+		Type:         EndpointTypeGRPC,
+		MethodName:   "/user.UserService/GetUser",
+		Timeout:      10 * time.Second,
+		Config:       &APICallerConfig{InsecureSkipVerify: true},
+	}
+	defer RemoveCallerFromCache(*endpoint, endpoint.Config)
+
+	type user struct {
+		UserID string `json:"user_id"`
+		Name   string `json:"name"`
+	}
+
+	// StringValue shares the wire format of GetUserRequest (field 1, string)
+	result, err := CallTyped[*wrapperspb.StringValue, user](context.Background(), endpoint, wrapperspb.String("typed-1"), nil)
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if result.Body.UserID != "typed-1" || result.Body.Name != "Test User" {
+		t.Errorf("Unexpected body: %+v", result.Body)
+	}
+	if result.GRPCStatus == nil || result.GRPCStatus.Code().String() != "OK" {
+		t.Errorf("Expected OK status, got %v", result.GRPCStatus)
+	}
+
+	// Generated response messages are decoded with protojson
+	protoResult, err := CallTyped[map[string]any, *structpb.Struct](context.Background(), endpoint, map[string]any{"user_id": "typed-2"}, nil)
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if protoResult.Body.GetFields()["user_id"].GetStringValue() != "typed-2" {
+		t.Errorf("Unexpected proto body: %v", protoResult.Body)
+	}
+}
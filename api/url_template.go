@@ -0,0 +1,150 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// resolveRequestURL builds the URL of a call from the endpoint URL template
+// options.URL, if set, replaces the template as is (e.g. a next page link).
+// {name} placeholders are replaced with the path-escaped options.PathParams values,
+// options.BaseURL replaces the scheme and host (a path in it prefixes the endpoint
+// path), and options.Query is appended to the query string of the URL.
+func resolveRequestURL(rawURL string, options *CallOptions) (string, error) {
+	expanded := options.URL
+	if expanded == "" {
+		var err error
+		if expanded, err = expandURLTemplate(rawURL, options.PathParams); err != nil {
+			return "", err
+		}
+	}
+	if options.BaseURL == "" && len(options.Query) == 0 {
+		return expanded, nil
+	}
+
+	requestURL, err := url.Parse(expanded)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint URL: %w", err)
+	}
+	if options.BaseURL != "" {
+		base, err := url.Parse(options.BaseURL)
+		if err != nil || base.Scheme == "" || base.Host == "" {
+			return "", fmt.Errorf("invalid base URL '%s'", options.BaseURL)
+		}
+		requestURL.Scheme = base.Scheme
+		requestURL.Host = base.Host
+		requestURL.User = base.User
+		// A base path is prefixed to the endpoint path
+		if basePath := strings.TrimSuffix(base.EscapedPath(), "/"); basePath != "" {
+			escapedPath := requestURL.EscapedPath()
+			requestURL.Path = strings.TrimSuffix(base.Path, "/") + requestURL.Path
+			requestURL.RawPath = basePath + escapedPath
+		}
+	}
+	if len(options.Query) > 0 {
+		query := requestURL.Query()
+		for key, values := range options.Query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		requestURL.RawQuery = query.Encode()
+	}
+	return requestURL.String(), nil
+}
+
+// urlPlaceholder matches a well-formed {name} placeholder
+var urlPlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// expandURLTemplate replaces {name} placeholders with path-escaped values
+// Other braces, such as a lone '{' or a JSON query value, are kept literally.
+// A placeholder without a value is an error; values without a placeholder are ignored.
+func expandURLTemplate(template string, values map[string]string) (string, error) {
+	matches := urlPlaceholder.FindAllStringSubmatchIndex(template, -1)
+	if len(matches) == 0 {
+		return template, nil
+	}
+
+	var expanded strings.Builder
+	last := 0
+	for _, match := range matches {
+		name := template[match[2]:match[3]]
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("missing path parameter '%s' for URL '%s'", name, template)
+		}
+		expanded.WriteString(template[last:match[0]])
+		expanded.WriteString(url.PathEscape(value))
+		last = match[1]
+	}
+	expanded.WriteString(template[last:])
+	return expanded.String(), nil
+}
+
+// pathParams converts the "path" call parameter to placeholder values
+func pathParams(value any) (map[string]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]string:
+		return v, nil
+	case map[string]any:
+		values := make(map[string]string, len(v))
+		for key, entry := range v {
+			values[key] = fmt.Sprint(entry)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported path parameters type: %T", value)
+	}
+}
+
+// queryParams converts the "query" call parameter to url.Values
+func queryParams(value any) (url.Values, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case url.Values:
+		return v, nil
+	case map[string][]string:
+		return url.Values(v), nil
+	case map[string]string:
+		values := make(url.Values, len(v))
+		for key, entry := range v {
+			values.Set(key, entry)
+		}
+		return values, nil
+	case map[string]any:
+		values := make(url.Values, len(v))
+		for key, entry := range v {
+			switch list := entry.(type) {
+			case []string:
+				values[key] = append(values[key], list...)
+			case []any:
+				for _, item := range list {
+					values.Add(key, fmt.Sprint(item))
+				}
+			default:
+				values.Set(key, fmt.Sprint(entry))
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported query parameters type: %T", value)
+	}
+}
+
+// callURL returns the URL a call is sent to, for logging and recording
+// Endpoint types without URL templates, and URLs that fail to resolve, return endpoint.URL.
+func callURL(endpoint Endpoint, options *CallOptions) string {
+	if !isHTTPMethodEndpoint(endpoint.Type) {
+		return endpoint.URL
+	}
+	resolved, err := resolveRequestURL(endpoint.URL, options)
+	if err != nil {
+		return endpoint.URL
+	}
+	return resolved
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestResolveRequestURL verifies placeholder expansion, query merging and base URL overrides
+func TestResolveRequestURL(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		url      string
+		options  CallOptions
+		expected string
+	}{
+		{"plain", "https://api.example.com/users", CallOptions{}, "https://api.example.com/users"},
+		{"escaped", "https://api.example.com/users/{id}", CallOptions{PathParams: map[string]string{"id": "a/b c"}}, "https://api.example.com/users/a%2Fb%20c"},
+		{"query", "https://api.example.com/users?limit=5", CallOptions{Query: url.Values{"q": {"x&y"}}}, "https://api.example.com/users?limit=5&q=x%26y"},
+		{"base", "https://api.example.com/users/{id}", CallOptions{PathParams: map[string]string{"id": "7"}, BaseURL: "http://localhost:8080"}, "http://localhost:8080/users/7"},
+		{"base path", "https://api.example.com/users", CallOptions{BaseURL: "http://localhost:8080/mock/"}, "http://localhost:8080/mock/users"},
+		{"literal brace", "https://api.example.com/users/{id", CallOptions{PathParams: map[string]string{"id": "7"}}, "https://api.example.com/users/{id"},
+		{"literal json", `https://api.example.com/users/{id}?filter={"a":1}`, CallOptions{PathParams: map[string]string{"id": "7"}}, `https://api.example.com/users/7?filter={"a":1}`},
+	} {
+		resolved, err := resolveRequestURL(tc.url, &tc.options)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if resolved != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, resolved)
+		}
+	}
+
+	for _, tc := range []struct {
+		url     string
+		options CallOptions
+	}{
+		{"https://api.example.com/users/{id}", CallOptions{}},
+		{"https://api.example.com/users", CallOptions{BaseURL: "localhost"}},
+	} {
+		if _, err := resolveRequestURL(tc.url, &tc.options); err == nil {
+			t.Errorf("Expected an error resolving %s with %+v", tc.url, tc.options)
+		}
+	}
+}
+
+// TestPathAndQueryParams verifies path and query parameters share one cached caller
+func TestPathAndQueryParams(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{FriendlyName: "Test URL Template", URL: "http://unused.test/users/{id}?v=1", Type: EndpointTypeREST, Timeout: 5 * time.Second}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	before := CallerCacheSize()
+	for _, id := range []any{"42", 7} {
+		_, err := endpoint.Call(map[string]any{
+			"path":    map[string]any{"id": id},
+			"query":   map[string]any{"tag": []string{"a", "b"}},
+			"baseURL": server.URL,
+		})
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+	}
+	if CallerCacheSize() != before+1 {
+		t.Errorf("Expected one cached caller, got %d new", CallerCacheSize()-before)
+	}
+	expected := []string{"/users/42?tag=a&tag=b&v=1", "/users/7?tag=a&tag=b&v=1"}
+	if len(requests) != 2 || requests[0] != expected[0] || requests[1] != expected[1] {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+
+	if _, err := endpoint.Call(map[string]any{"baseURL": server.URL}); err == nil {
+		t.Error("Expected an error for a missing path parameter")
+	}
+	if _, err := endpoint.Call(map[string]any{"path": map[string]string{"id": "1"}, "query": 5}); err == nil {
+		t.Error("Expected an error for an unsupported query type")
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected failed calls not to be sent, got %d requests", len(requests))
+	}
+}
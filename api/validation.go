@@ -0,0 +1,550 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrResponseInvalid is returned when a response fails Endpoint.ExpectedStatusCodes
+// or Endpoint.ResponseSchema validation
+var ErrResponseInvalid = errors.New("response validation failed")
+
+// SchemaViolation is a single JSON Schema violation in a response body
+type SchemaViolation struct {
+	// Path locates the offending value, e.g. "$.items[2].id"
+	Path string
+	// Message describes the violated constraint
+	Message string
+}
+
+// String returns the violation as "path: message"
+func (v SchemaViolation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ResponseValidationError is the typed error returned when a successful response
+// has an unexpected status code or a body violating the endpoint response schema.
+// It matches ErrResponseInvalid with errors.Is
+type ResponseValidationError struct {
+	// FriendlyName is the name of the endpoint
+	FriendlyName string
+	// StatusCode is the status code of the response
+	StatusCode int
+	// ExpectedStatusCodes is set when StatusCode is not one of them
+	ExpectedStatusCodes []int
+	// Violations lists every schema violation found in the body
+	Violations []SchemaViolation
+}
+
+// Error implements the error interface
+func (e *ResponseValidationError) Error() string {
+	if len(e.ExpectedStatusCodes) > 0 {
+		return fmt.Sprintf("endpoint '%s' returned status %d, expected one of %v", e.FriendlyName, e.StatusCode, e.ExpectedStatusCodes)
+	}
+	violations := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		violations = append(violations, violation.String())
+	}
+	return fmt.Sprintf("response from endpoint '%s' violates schema: %s", e.FriendlyName, strings.Join(violations, "; "))
+}
+
+// Unwrap allows errors.Is(err, ErrResponseInvalid)
+func (e *ResponseValidationError) Unwrap() error {
+	return ErrResponseInvalid
+}
+
+// validateResponse checks a successful response against the endpoint status codes and schema
+// Streamed bodies are not read, so only their status code is checked; a streamed body
+// with an unexpected status is closed.
+func (e *Endpoint) validateResponse(caller *APICaller, response *Response) error {
+	if response == nil || (len(e.ExpectedStatusCodes) == 0 && len(e.ResponseSchema) == 0) {
+		return nil
+	}
+	if len(e.ExpectedStatusCodes) > 0 && !slices.Contains(e.ExpectedStatusCodes, response.StatusCode) {
+		if closer, ok := response.Body.(io.Closer); ok {
+			closer.Close()
+		}
+		return &ResponseValidationError{
+			FriendlyName:        e.FriendlyName,
+			StatusCode:          response.StatusCode,
+			ExpectedStatusCodes: e.ExpectedStatusCodes,
+		}
+	}
+	if len(e.ResponseSchema) == 0 {
+		return nil
+	}
+	if _, streamed := response.Body.(io.Reader); streamed {
+		return nil
+	}
+
+	schema, err := e.compiledResponseSchema(caller)
+	if err != nil {
+		return err
+	}
+	document, err := responseDocument(e.Type, response.Body)
+	if err != nil {
+		return err
+	}
+	if violations := schema.validate(document); len(violations) > 0 {
+		return &ResponseValidationError{
+			FriendlyName: e.FriendlyName,
+			StatusCode:   response.StatusCode,
+			Violations:   violations,
+		}
+	}
+	return nil
+}
+
+// responseDocument returns the body as the generic JSON value Endpoint.Call reports
+func responseDocument(endpointType EndpointType, body any) (any, error) {
+	switch v := body.(type) {
+	case nil:
+		return nil, nil
+	case *GraphQLResponse:
+		var data any
+		if len(v.Data) > 0 {
+			if err := json.Unmarshal(v.Data, &data); err != nil {
+				return nil, fmt.Errorf("failed to parse GraphQL data: %w", err)
+			}
+		}
+		return data, nil
+	case map[string]any:
+		return v, nil
+	case []byte:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		if endpointType == EndpointTypeSOAP {
+			if parsed, err := parseSOAPResponse(v); err == nil && parsed != nil {
+				return parsed, nil
+			}
+			return string(v), nil
+		}
+		var document any
+		if err := json.Unmarshal(v, &document); err != nil {
+			return string(v), nil
+		}
+		return document, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert response body for validation: %w", err)
+		}
+		var document any
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to convert response body for validation: %w", err)
+		}
+		return document, nil
+	}
+}
+
+// jsonSchema is a compiled JSON Schema supporting the validation keywords of draft 2020-12
+// that apply to plain JSON: type, enum, const, properties, required, additionalProperties,
+// patternProperties, min/maxProperties, items, min/maxItems, uniqueItems, min/maxLength,
+// pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf,
+// oneOf, not and local $ref ("#", "#/$defs/name" or "#/definitions/name").
+// Other keywords, including format, are ignored.
+type jsonSchema struct {
+	// digest is the SHA-256 of the schema document this was compiled from
+	digest   [sha256.Size]byte
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// compiledResponseSchema returns the compiled Endpoint.ResponseSchema
+// Endpoints loaded from a catalog carry it compiled; others compile each distinct
+// schema once per cached caller, keyed by its digest.
+func (e *Endpoint) compiledResponseSchema(caller *APICaller) (*jsonSchema, error) {
+	digest := sha256.Sum256(e.ResponseSchema)
+	if e.responseSchema != nil && e.responseSchema.digest == digest {
+		return e.responseSchema, nil
+	}
+	if caller != nil {
+		if cached, ok := caller.responseSchemas.Load(digest); ok {
+			return cached.(*jsonSchema), nil
+		}
+	}
+	compiled, err := compileJSONSchema(e.ResponseSchema)
+	if err != nil {
+		return nil, err
+	}
+	if caller != nil {
+		cached, _ := caller.responseSchemas.LoadOrStore(digest, compiled)
+		return cached.(*jsonSchema), nil
+	}
+	return compiled, nil
+}
+
+// compileJSONSchema parses schema, compiling patterns and checking $ref targets
+func compileJSONSchema(schema []byte) (*jsonSchema, error) {
+	var root any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	compiled := &jsonSchema{digest: sha256.Sum256(schema), root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := compiled.compile(root); err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// compile walks a (sub)schema, checking its structure
+func (s *jsonSchema) compile(node any) error {
+	switch v := node.(type) {
+	case bool:
+		return nil
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok {
+			if _, err := s.resolve(ref); err != nil {
+				return err
+			}
+		}
+		var expressions []string
+		if pattern, ok := v["pattern"].(string); ok {
+			expressions = append(expressions, pattern)
+		}
+		if patternProperties, ok := v["patternProperties"].(map[string]any); ok {
+			for pattern := range patternProperties {
+				expressions = append(expressions, pattern)
+			}
+		}
+		for _, expression := range expressions {
+			pattern, err := regexp.Compile(expression)
+			if err != nil {
+				return fmt.Errorf("invalid pattern '%s': %w", expression, err)
+			}
+			s.patterns[expression] = pattern
+		}
+		for _, keyword := range []string{"properties", "patternProperties", "$defs", "definitions"} {
+			if children, ok := v[keyword].(map[string]any); ok {
+				for _, child := range children {
+					if err := s.compile(child); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, keyword := range []string{"additionalProperties", "items", "not"} {
+			if child, ok := v[keyword]; ok {
+				if err := s.compile(child); err != nil {
+					return err
+				}
+			}
+		}
+		for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+			if children, ok := v[keyword].([]any); ok {
+				for _, child := range children {
+					if err := s.compile(child); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("schema must be an object or boolean, got %T", node)
+	}
+}
+
+// resolve returns the subschema a local $ref points to
+func (s *jsonSchema) resolve(ref string) (any, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref '%s': only local references are supported", ref)
+	}
+	node := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref '%s'", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref '%s'", ref)
+		}
+	}
+	return node, nil
+}
+
+// validate returns every violation of the schema by document
+func (s *jsonSchema) validate(document any) []SchemaViolation {
+	var violations []SchemaViolation
+	s.validateNode(s.root, document, "$", &violations, 0)
+	return violations
+}
+
+// maxSchemaDepth bounds $ref recursion for self-referencing schemas
+const maxSchemaDepth = 64
+
+// validateNode appends the violations of schema by value at path
+func (s *jsonSchema) validateNode(schema any, value any, path string, violations *[]SchemaViolation, depth int) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if depth > maxSchemaDepth {
+		report("schema nesting exceeds %d levels", maxSchemaDepth)
+		return
+	}
+
+	switch v := schema.(type) {
+	case bool:
+		if !v {
+			report("no value is allowed")
+		}
+		return
+	case map[string]any:
+		schema := v
+		if ref, ok := schema["$ref"].(string); ok {
+			target, _ := s.resolve(ref)
+			s.validateNode(target, value, path, violations, depth+1)
+		}
+
+		if expected, ok := schema["type"]; ok && !matchesType(expected, value) {
+			report("expected %s, got %s", typeList(expected), jsonTypeName(value))
+			return
+		}
+		if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(item any) bool { return reflect.DeepEqual(item, value) }) {
+			report("value %s is not one of %s", jsonText(value), jsonText(enum))
+		}
+		if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+			report("value %s is not %s", jsonText(value), jsonText(constant))
+		}
+
+		switch typed := value.(type) {
+		case map[string]any:
+			s.validateObject(schema, typed, path, violations, depth, report)
+		case []any:
+			s.validateArray(schema, typed, path, violations, depth, report)
+		case string:
+			length := float64(utf8.RuneCountInString(typed))
+			if limit, ok := schema["minLength"].(float64); ok && length < limit {
+				report("length %v is less than minLength %v", length, limit)
+			}
+			if limit, ok := schema["maxLength"].(float64); ok && length > limit {
+				report("length %v is greater than maxLength %v", length, limit)
+			}
+			if pattern, ok := schema["pattern"].(string); ok && !s.matchPattern(pattern, typed) {
+				report("value %s does not match pattern '%s'", jsonText(typed), pattern)
+			}
+		case float64:
+			if limit, ok := schema["minimum"].(float64); ok && typed < limit {
+				report("value %v is less than minimum %v", typed, limit)
+			}
+			if limit, ok := schema["maximum"].(float64); ok && typed > limit {
+				report("value %v is greater than maximum %v", typed, limit)
+			}
+			if limit, ok := schema["exclusiveMinimum"].(float64); ok && typed <= limit {
+				report("value %v is not greater than %v", typed, limit)
+			}
+			if limit, ok := schema["exclusiveMaximum"].(float64); ok && typed >= limit {
+				report("value %v is not less than %v", typed, limit)
+			}
+			if divisor, ok := schema["multipleOf"].(float64); ok && divisor > 0 {
+				if quotient := typed / divisor; math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+					report("value %v is not a multiple of %v", typed, divisor)
+				}
+			}
+		}
+
+		if allOf, ok := schema["allOf"].([]any); ok {
+			for _, child := range allOf {
+				s.validateNode(child, value, path, violations, depth+1)
+			}
+		}
+		if anyOf, ok := schema["anyOf"].([]any); ok && s.countMatches(anyOf, value, path, depth) == 0 {
+			report("value does not match any schema in anyOf")
+		}
+		if oneOf, ok := schema["oneOf"].([]any); ok {
+			if matches := s.countMatches(oneOf, value, path, depth); matches != 1 {
+				report("value matches %d schemas in oneOf, expected exactly 1", matches)
+			}
+		}
+		if not, ok := schema["not"]; ok && s.countMatches([]any{not}, value, path, depth) == 1 {
+			report("value must not match the schema in not")
+		}
+	}
+}
+
+// validateObject checks the object keywords of schema
+func (s *jsonSchema) validateObject(schema map[string]any, object map[string]any, path string, violations *[]SchemaViolation, depth int, report func(string, ...any)) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := object[name]; !present {
+					report("missing required property '%s'", name)
+				}
+			}
+		}
+	}
+	count := float64(len(object))
+	if limit, ok := schema["minProperties"].(float64); ok && count < limit {
+		report("has %v properties, fewer than minProperties %v", count, limit)
+	}
+	if limit, ok := schema["maxProperties"].(float64); ok && count > limit {
+		report("has %v properties, more than maxProperties %v", count, limit)
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	patternProperties, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+
+	// Visit properties in a stable order so violations are reported deterministically
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		childPath := propertyPath(path, name)
+		matched := false
+		if child, ok := properties[name]; ok {
+			matched = true
+			s.validateNode(child, object[name], childPath, violations, depth+1)
+		}
+		for pattern, child := range patternProperties {
+			if s.matchPattern(pattern, name) {
+				matched = true
+				s.validateNode(child, object[name], childPath, violations, depth+1)
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				report("unexpected property '%s'", name)
+			} else {
+				s.validateNode(additional, object[name], childPath, violations, depth+1)
+			}
+		}
+	}
+}
+
+// validateArray checks the array keywords of schema
+func (s *jsonSchema) validateArray(schema map[string]any, array []any, path string, violations *[]SchemaViolation, depth int, report func(string, ...any)) {
+	count := float64(len(array))
+	if limit, ok := schema["minItems"].(float64); ok && count < limit {
+		report("has %v items, fewer than minItems %v", count, limit)
+	}
+	if limit, ok := schema["maxItems"].(float64); ok && count > limit {
+		report("has %v items, more than maxItems %v", count, limit)
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if reflect.DeepEqual(array[i], array[j]) {
+					report("items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+	if items, ok := schema["items"]; ok {
+		for i, item := range array {
+			s.validateNode(items, item, path+"["+strconv.Itoa(i)+"]", violations, depth+1)
+		}
+	}
+}
+
+// matchPattern reports whether value matches the ECMA-262 style pattern
+// Patterns of subschemas only reachable through $ref are compiled on use.
+func (s *jsonSchema) matchPattern(pattern string, value string) bool {
+	if compiled, ok := s.patterns[pattern]; ok {
+		return compiled.MatchString(value)
+	}
+	compiled, err := regexp.Compile(pattern)
+	return err == nil && compiled.MatchString(value)
+}
+
+// countMatches returns how many of schemas value satisfies
+func (s *jsonSchema) countMatches(schemas []any, value any, path string, depth int) int {
+	matches := 0
+	for _, child := range schemas {
+		var childViolations []SchemaViolation
+		s.validateNode(child, value, path, &childViolations, depth+1)
+		if len(childViolations) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// matchesType reports whether value has the schema type (a name or a list of names)
+func matchesType(expected any, value any) bool {
+	switch v := expected.(type) {
+	case string:
+		actual := jsonTypeName(value)
+		return actual == v || (v == "number" && actual == "integer")
+	case []any:
+		for _, name := range v {
+			if matchesType(name, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// jsonTypeName returns the JSON Schema type name of a decoded JSON value
+func jsonTypeName(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// typeList formats a schema type keyword for messages
+func typeList(expected any) string {
+	if names, ok := expected.([]any); ok {
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprint(name))
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(expected)
+}
+
+// jsonText formats a value as compact JSON for messages
+func jsonText(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// propertyPath appends an object property to a violation path
+func propertyPath(path string, name string) string {
+	if name != "" && strings.IndexFunc(name, func(r rune) bool {
+		return !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	}) < 0 {
+		return path + "." + name
+	}
+	return path + "[" + strconv.Quote(name) + "]"
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// userSchema is the response schema used by the validation tests
+const userSchema = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 1},
+		"roles": {"type": "array", "items": {"$ref": "#/$defs/role"}, "uniqueItems": true}
+	},
+	"$defs": {
+		"role": {"enum": ["admin", "reader"]}
+	}
+}`
+
+// TestResponseSchemaViolations verifies every violation is reported with its path
+func TestResponseSchemaViolations(t *testing.T) {
+	schema, err := compileJSONSchema([]byte(userSchema))
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+
+	valid := map[string]any{"id": 7.0, "name": "Ada", "roles": []any{"admin", "reader"}}
+	if violations := schema.validate(valid); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+
+	invalid := map[string]any{"id": 1.5, "roles": []any{"owner", "owner"}, "extra-field": true}
+	expected := []string{
+		"$: missing required property 'name'",
+		`$: unexpected property 'extra-field'`,
+		"$.id: expected integer, got number",
+		`$.roles: items 0 and 1 are equal`,
+		`$.roles[0]: value "owner" is not one of ["admin","reader"]`,
+		`$.roles[1]: value "owner" is not one of ["admin","reader"]`,
+	}
+	violations := schema.validate(invalid)
+	if len(violations) != len(expected) {
+		t.Fatalf("Expected %d violations, got %v", len(expected), violations)
+	}
+	for i, violation := range violations {
+		if violation.String() != expected[i] {
+			t.Errorf("Violation %d: expected %q, got %q", i, expected[i], violation.String())
+		}
+	}
+
+	combined, err := compileJSONSchema([]byte(`{"oneOf": [{"type": "string", "pattern": "^a"}, {"type": "string", "maxLength": 2}], "not": {"const": "ab"}}`))
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	for value, count := range map[string]int{"abc": 0, "b": 0, "ab": 2, "bcd": 1} {
+		if violations := combined.validate(value); len(violations) != count {
+			t.Errorf("Expected %d violations for %q, got %v", count, value, violations)
+		}
+	}
+
+	for _, bad := range []string{`[1]`, `{"$ref": "#/$defs/missing"}`, `{"pattern": "("}`, `{"$ref": "other.json"}`} {
+		if _, err := compileJSONSchema([]byte(bad)); err == nil {
+			t.Errorf("Expected an error compiling %s", bad)
+		}
+	}
+}
+
+// TestEndpointResponseValidation verifies Endpoint.Call enforces status codes and the schema
+func TestEndpointResponseValidation(t *testing.T) {
+	var status int
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName:        "Test Validation",
+		URL:                 server.URL,
+		Type:                EndpointTypeREST,
+		Timeout:             5 * time.Second,
+		ExpectedStatusCodes: []int{http.StatusOK},
+		ResponseSchema:      []byte(userSchema),
+		MaxRetries:          2,
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	status, body = http.StatusOK, `{"id": 3, "name": "Grace"}`
+	if _, err := endpoint.Call(nil); err != nil {
+		t.Fatalf("Expected a valid response, got %v", err)
+	}
+
+	status, body = http.StatusOK, `{"id": "3"}`
+	result, err := endpoint.Call(nil)
+	var validationErr *ResponseValidationError
+	if !errors.As(err, &validationErr) || !errors.Is(err, ErrResponseInvalid) {
+		t.Fatalf("Expected *ResponseValidationError, got %T: %v", err, err)
+	}
+	if len(validationErr.Violations) != 2 || validationErr.StatusCode != http.StatusOK {
+		t.Errorf("Expected 2 violations, got %v", validationErr.Violations)
+	}
+	if result["body"] == nil || !strings.Contains(result["error"].(string), "$.id: expected integer, got string") {
+		t.Errorf("Expected the body and error in the result, got %v", result)
+	}
+
+	status, body = http.StatusAccepted, `{"id": 3, "name": "Grace"}`
+	if _, err := endpoint.Call(nil); !errors.As(err, &validationErr) || validationErr.StatusCode != http.StatusAccepted || validationErr.Violations != nil {
+		t.Errorf("Expected an unexpected status error, got %v", err)
+	}
+
+	// Typed calls validate the same way
+	status, body = http.StatusOK, `{"id": 0, "name": ""}`
+	if _, err := CallTyped[any, map[string]any](context.Background(), &endpoint, nil, nil); !errors.As(err, &validationErr) || len(validationErr.Violations) != 2 {
+		t.Errorf("Expected 2 violations from CallTyped, got %v", err)
+	}
+
+	// The schema is compiled once for the cached caller
+	caller, _ := GetOrCreateAPICaller(endpoint, nil)
+	first, err := endpoint.compiledResponseSchema(caller)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	if cached, ok := caller.responseSchemas.Load(sha256.Sum256([]byte(userSchema))); !ok || cached != first {
+		t.Error("Expected the compiled schema to be kept by the caller")
+	}
+
+	// Endpoints sharing the caller keep their own compiled schemas
+	other := endpoint
+	other.ResponseSchema = []byte(`{"type": "object"}`)
+	otherSchema, _ := other.compiledResponseSchema(caller)
+	if again, _ := endpoint.compiledResponseSchema(caller); again != first || otherSchema == first {
+		t.Error("Expected each schema to be compiled once per caller")
+	}
+	if again, _ := other.compiledResponseSchema(caller); again != otherSchema {
+		t.Error("Expected the second schema to stay cached")
+	}
+
+	// A streamed body with an unexpected status is closed
+	status = http.StatusAccepted
+	result, err = endpoint.Call(map[string]any{"stream": true})
+	stream, _ := result["body"].(io.ReadCloser)
+	if !errors.As(err, &validationErr) || stream == nil {
+		t.Fatalf("Expected an unexpected status error with the stream, got %v", err)
+	}
+	if _, err := stream.Read(make([]byte, 1)); err == nil || err == io.EOF {
+		t.Errorf("Expected the stream to be closed, got %v", err)
+	}
+}
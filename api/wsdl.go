@@ -0,0 +1,511 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// wsdlSOAP11BindingNamespace is the WSDL binding extension namespace for SOAP 1.1
+	wsdlSOAP11BindingNamespace = "http://schemas.xmlsoap.org/wsdl/soap/"
+	// wsdlSOAP12BindingNamespace is the WSDL binding extension namespace for SOAP 1.2
+	wsdlSOAP12BindingNamespace = "http://schemas.xmlsoap.org/wsdl/soap12/"
+)
+
+// WSDL is a parsed WSDL 1.1 document describing the operations of a SOAP service
+type WSDL struct {
+	// TargetNamespace is the target namespace of the WSDL definitions
+	TargetNamespace string
+	// Operations are the operations exposed by the service bindings
+	Operations []WSDLOperation
+
+	schemas []xsdSchema
+}
+
+// WSDLOperation describes a single SOAP operation discovered from a WSDL
+type WSDLOperation struct {
+	// Name is the operation name
+	Name string
+	// SOAPAction is the SOAPAction declared by the SOAP 1.1 binding (may be empty)
+	SOAPAction string
+	// SOAP12Action is the action declared by the SOAP 1.2 binding (may be empty)
+	SOAP12Action string
+	// Style is the binding style ("document" or "rpc")
+	Style string
+	// Namespace is the namespace of the request element
+	Namespace string
+	// InputElement is the local name of the request element
+	InputElement string
+	// OutputElement is the local name of the response element
+	OutputElement string
+	// BindingNamespace is the namespace of the SOAP binding extension the operation was first read from
+	BindingNamespace string
+
+	// rpcParts are the input message parts for rpc style operations
+	rpcParts []xsdElement
+}
+
+// wsdlDefinitions mirrors the parts of a WSDL 1.1 document used for discovery
+type wsdlDefinitions struct {
+	TargetNamespace string `xml:"targetNamespace,attr"`
+	Types           struct {
+		Schemas []xsdSchema `xml:"schema"`
+	} `xml:"types"`
+	Messages []struct {
+		Name  string `xml:"name,attr"`
+		Parts []struct {
+			Name    string `xml:"name,attr"`
+			Element string `xml:"element,attr"`
+			Type    string `xml:"type,attr"`
+		} `xml:"part"`
+	} `xml:"message"`
+	PortTypes []struct {
+		Name       string `xml:"name,attr"`
+		Operations []struct {
+			Name  string `xml:"name,attr"`
+			Input struct {
+				Message string `xml:"message,attr"`
+			} `xml:"input"`
+			Output struct {
+				Message string `xml:"message,attr"`
+			} `xml:"output"`
+		} `xml:"operation"`
+	} `xml:"portType"`
+	Bindings []struct {
+		Name    string `xml:"name,attr"`
+		Type    string `xml:"type,attr"`
+		Binding struct {
+			XMLName xml.Name
+			Style   string `xml:"style,attr"`
+		} `xml:"binding"`
+		Operations []struct {
+			Name      string `xml:"name,attr"`
+			Operation struct {
+				SOAPAction string `xml:"soapAction,attr"`
+				Style      string `xml:"style,attr"`
+			} `xml:"operation"`
+			Input struct {
+				Body struct {
+					Namespace string `xml:"namespace,attr"`
+				} `xml:"body"`
+			} `xml:"input"`
+		} `xml:"operation"`
+	} `xml:"binding"`
+}
+
+// xsdSchema mirrors an XML Schema embedded in the WSDL types section
+type xsdSchema struct {
+	TargetNamespace    string           `xml:"targetNamespace,attr"`
+	ElementFormDefault string           `xml:"elementFormDefault,attr"`
+	Elements           []xsdElement     `xml:"element"`
+	ComplexTypes       []xsdComplexType `xml:"complexType"`
+}
+
+// xsdElement mirrors an XML Schema element declaration
+type xsdElement struct {
+	Name        string          `xml:"name,attr"`
+	Type        string          `xml:"type,attr"`
+	Ref         string          `xml:"ref,attr"`
+	MaxOccurs   string          `xml:"maxOccurs,attr"`
+	ComplexType *xsdComplexType `xml:"complexType"`
+}
+
+// xsdComplexType mirrors an XML Schema complex type
+type xsdComplexType struct {
+	Name           string    `xml:"name,attr"`
+	Sequence       *xsdGroup `xml:"sequence"`
+	All            *xsdGroup `xml:"all"`
+	Choice         *xsdGroup `xml:"choice"`
+	ComplexContent *struct {
+		Extension *struct {
+			Base     string    `xml:"base,attr"`
+			Sequence *xsdGroup `xml:"sequence"`
+		} `xml:"extension"`
+	} `xml:"complexContent"`
+}
+
+// xsdGroup mirrors a sequence, all or choice model group
+type xsdGroup struct {
+	Elements []xsdElement `xml:"element"`
+}
+
+// ParseWSDL parses a WSDL 1.1 document and discovers its SOAP operations
+func ParseWSDL(data []byte) (*WSDL, error) {
+	var defs wsdlDefinitions
+	if err := xml.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("failed to parse WSDL: %w", err)
+	}
+
+	wsdl := &WSDL{
+		TargetNamespace: defs.TargetNamespace,
+		schemas:         defs.Types.Schemas,
+	}
+
+	// Index input/output messages by operation name from the port types
+	type operationMessages struct {
+		input  string
+		output string
+	}
+	portOperations := make(map[string]operationMessages)
+	for _, portType := range defs.PortTypes {
+		for _, op := range portType.Operations {
+			portOperations[op.Name] = operationMessages{
+				input:  localName(op.Input.Message),
+				output: localName(op.Output.Message),
+			}
+		}
+	}
+
+	// messageParts returns the element (or part list) of a message
+	messageParts := func(name string) (string, []xsdElement) {
+		for _, msg := range defs.Messages {
+			if msg.Name != name {
+				continue
+			}
+			var parts []xsdElement
+			for _, part := range msg.Parts {
+				if part.Element != "" {
+					return localName(part.Element), nil
+				}
+				parts = append(parts, xsdElement{Name: part.Name, Type: part.Type})
+			}
+			return "", parts
+		}
+		return "", nil
+	}
+
+	// Prefer SOAP 1.1 bindings, then any other SOAP binding declaring the operation
+	bindings := defs.Bindings
+	sort.SliceStable(bindings, func(i, j int) bool {
+		return bindings[i].Binding.XMLName.Space == wsdlSOAP11BindingNamespace &&
+			bindings[j].Binding.XMLName.Space != wsdlSOAP11BindingNamespace
+	})
+
+	for _, binding := range bindings {
+		// Skip non-SOAP bindings (e.g., HTTP GET/POST)
+		space := binding.Binding.XMLName.Space
+		if space != wsdlSOAP11BindingNamespace && space != wsdlSOAP12BindingNamespace {
+			continue
+		}
+		for _, bindingOp := range binding.Operations {
+			// Operations seen in a previous binding only pick up the action of this binding
+			if existing, ok := wsdl.Operation(bindingOp.Name); ok {
+				if space == wsdlSOAP12BindingNamespace && existing.SOAP12Action == "" {
+					existing.SOAP12Action = bindingOp.Operation.SOAPAction
+				} else if space == wsdlSOAP11BindingNamespace && existing.SOAPAction == "" {
+					existing.SOAPAction = bindingOp.Operation.SOAPAction
+				}
+				continue
+			}
+
+			style := bindingOp.Operation.Style
+			if style == "" {
+				style = binding.Binding.Style
+			}
+			if style == "" {
+				style = "document"
+			}
+
+			op := WSDLOperation{
+				Name:             bindingOp.Name,
+				Style:            style,
+				Namespace:        defs.TargetNamespace,
+				BindingNamespace: space,
+			}
+			if space == wsdlSOAP12BindingNamespace {
+				op.SOAP12Action = bindingOp.Operation.SOAPAction
+			} else {
+				op.SOAPAction = bindingOp.Operation.SOAPAction
+			}
+
+			messages := portOperations[bindingOp.Name]
+			inputElement, inputParts := messageParts(messages.input)
+			outputElement, _ := messageParts(messages.output)
+
+			if style == "rpc" || inputElement == "" {
+				// RPC style wraps the parts in an element named after the operation
+				op.InputElement = bindingOp.Name
+				op.OutputElement = bindingOp.Name + "Response"
+				op.rpcParts = inputParts
+				if bindingOp.Input.Body.Namespace != "" {
+					op.Namespace = bindingOp.Input.Body.Namespace
+				}
+			} else {
+				op.InputElement = inputElement
+				op.OutputElement = outputElement
+				if schema, _ := wsdl.findElement(inputElement); schema != nil && schema.TargetNamespace != "" {
+					op.Namespace = schema.TargetNamespace
+				}
+			}
+
+			wsdl.Operations = append(wsdl.Operations, op)
+		}
+	}
+
+	return wsdl, nil
+}
+
+// Operation returns the operation with the given name
+func (w *WSDL) Operation(name string) (*WSDLOperation, bool) {
+	for i := range w.Operations {
+		if w.Operations[i].Name == name {
+			return &w.Operations[i], true
+		}
+	}
+	return nil, false
+}
+
+// action returns the action for the given SOAP version, falling back to the other binding
+func (op *WSDLOperation) action(version SOAPVersion) string {
+	if version == SOAPVersion12 && op.SOAP12Action != "" {
+		return op.SOAP12Action
+	}
+	if op.SOAPAction != "" {
+		return op.SOAPAction
+	}
+	return op.SOAP12Action
+}
+
+// OperationNames returns the names of all discovered operations
+func (w *WSDL) OperationNames() []string {
+	names := make([]string, 0, len(w.Operations))
+	for _, op := range w.Operations {
+		names = append(names, op.Name)
+	}
+	return names
+}
+
+// findElement finds a top-level schema element by local name
+func (w *WSDL) findElement(name string) (*xsdSchema, *xsdElement) {
+	for i := range w.schemas {
+		for j := range w.schemas[i].Elements {
+			if w.schemas[i].Elements[j].Name == name {
+				return &w.schemas[i], &w.schemas[i].Elements[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findComplexType finds a named complex type by local name
+func (w *WSDL) findComplexType(name string) (*xsdSchema, *xsdComplexType) {
+	for i := range w.schemas {
+		for j := range w.schemas[i].ComplexTypes {
+			if w.schemas[i].ComplexTypes[j].Name == name {
+				return &w.schemas[i], &w.schemas[i].ComplexTypes[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// complexTypeChildren returns the child element declarations of a complex type, including extension bases
+func (w *WSDL) complexTypeChildren(ct *xsdComplexType, depth int) []xsdElement {
+	if ct == nil || depth > 16 {
+		return nil
+	}
+	var children []xsdElement
+	if ct.ComplexContent != nil && ct.ComplexContent.Extension != nil {
+		if _, base := w.findComplexType(localName(ct.ComplexContent.Extension.Base)); base != nil {
+			children = append(children, w.complexTypeChildren(base, depth+1)...)
+		}
+		if ct.ComplexContent.Extension.Sequence != nil {
+			children = append(children, ct.ComplexContent.Extension.Sequence.Elements...)
+		}
+	}
+	for _, group := range []*xsdGroup{ct.Sequence, ct.All, ct.Choice} {
+		if group != nil {
+			children = append(children, group.Elements...)
+		}
+	}
+	return children
+}
+
+// elementChildren returns the child element declarations of an element
+func (w *WSDL) elementChildren(el *xsdElement) []xsdElement {
+	if w == nil || el == nil {
+		return nil
+	}
+	if el.Ref != "" {
+		if _, ref := w.findElement(localName(el.Ref)); ref != nil {
+			el = ref
+		}
+	}
+	if el.ComplexType != nil {
+		return w.complexTypeChildren(el.ComplexType, 0)
+	}
+	if el.Type != "" {
+		if _, ct := w.findComplexType(localName(el.Type)); ct != nil {
+			return w.complexTypeChildren(ct, 0)
+		}
+	}
+	return nil
+}
+
+// localName strips the namespace prefix of a QName attribute value
+func localName(qname string) string {
+	if idx := strings.LastIndex(qname, ":"); idx >= 0 {
+		return qname[idx+1:]
+	}
+	return qname
+}
+
+// soapBodyEncoder serializes parameter maps into namespaced XML following the WSDL schema
+type soapBodyEncoder struct {
+	wsdl *WSDL
+	buf  bytes.Buffer
+	// err is the first invalid element name encountered
+	err error
+}
+
+// encodeOperationBody writes the request element of an operation with its parameters
+// If op is nil the element is named after operationName in namespace with schema-less serialization.
+// Element names that are not valid XML NCNames are rejected.
+func encodeOperationBody(wsdl *WSDL, op *WSDLOperation, operationName string, namespace string, params map[string]any) (string, error) {
+	enc := &soapBodyEncoder{wsdl: wsdl}
+
+	elementName := operationName
+	var decl *xsdElement
+	childNamespace := namespace
+
+	if op != nil {
+		elementName = op.InputElement
+		namespace = op.Namespace
+		childNamespace = namespace
+		if op.Style == "rpc" {
+			// RPC parts are unqualified
+			decl = &xsdElement{Name: elementName, ComplexType: &xsdComplexType{Sequence: &xsdGroup{Elements: op.rpcParts}}}
+			childNamespace = ""
+		} else if schema, el := wsdl.findElement(op.InputElement); el != nil {
+			decl = el
+			if schema.ElementFormDefault != "qualified" {
+				childNamespace = ""
+			}
+		}
+	}
+
+	if !isNCName(elementName) {
+		return "", fmt.Errorf("invalid XML element name %q", elementName)
+	}
+	fmt.Fprintf(&enc.buf, `<%s xmlns="%s">`, elementName, xmlEscapeString(namespace))
+	enc.writeChildren(params, decl, namespace, childNamespace)
+	fmt.Fprintf(&enc.buf, "</%s>", elementName)
+	if enc.err != nil {
+		return "", enc.err
+	}
+	return enc.buf.String(), nil
+}
+
+// writeChildren writes the entries of params as child elements, in schema order when known
+func (enc *soapBodyEncoder) writeChildren(params map[string]any, decl *xsdElement, inheritedNamespace string, childNamespace string) {
+	var children []xsdElement
+	if decl != nil {
+		children = enc.wsdl.elementChildren(decl)
+	}
+
+	// Schema declared children first, in declaration order, then remaining keys sorted
+	keys := make([]string, 0, len(params))
+	for _, child := range children {
+		if _, ok := params[child.Name]; ok && !slices.Contains(keys, child.Name) {
+			keys = append(keys, child.Name)
+		}
+	}
+	var extra []string
+	for key := range params {
+		if !slices.Contains(keys, key) {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	keys = append(keys, extra...)
+
+	for _, key := range keys {
+		var childDecl *xsdElement
+		for i := range children {
+			if children[i].Name == key {
+				childDecl = &children[i]
+				break
+			}
+		}
+		enc.writeElement(key, params[key], childDecl, inheritedNamespace, childNamespace)
+	}
+}
+
+// writeElement writes a single value; slices become repeated elements and maps nested elements
+// []byte values are written as xsd:base64Binary.
+func (enc *soapBodyEncoder) writeElement(name string, value any, decl *xsdElement, inheritedNamespace string, namespace string) {
+	if !isNCName(name) {
+		if enc.err == nil {
+			enc.err = fmt.Errorf("invalid XML element name %q", name)
+		}
+		return
+	}
+	if value != nil {
+		rv := reflect.ValueOf(value)
+		if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+			for i := 0; i < rv.Len(); i++ {
+				enc.writeElement(name, rv.Index(i).Interface(), decl, inheritedNamespace, namespace)
+			}
+			return
+		}
+	}
+
+	enc.buf.WriteString("<" + name)
+	if namespace != inheritedNamespace {
+		fmt.Fprintf(&enc.buf, ` xmlns="%s"`, xmlEscapeString(namespace))
+	}
+	enc.buf.WriteString(">")
+
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		// Nested elements follow the element form of the schema
+		enc.writeChildren(v, decl, namespace, namespace)
+	case map[string]string:
+		nested := make(map[string]any, len(v))
+		for key, val := range v {
+			nested[key] = val
+		}
+		enc.writeChildren(nested, decl, namespace, namespace)
+	case time.Time:
+		enc.buf.WriteString(v.Format(time.RFC3339Nano))
+	case []byte:
+		enc.buf.WriteString(base64.StdEncoding.EncodeToString(v))
+	default:
+		enc.buf.WriteString(xmlEscapeString(fmt.Sprint(v)))
+	}
+
+	enc.buf.WriteString("</" + name + ">")
+}
+
+// isNCName reports whether name matches the XML NCName production: a name without colons
+// starting with a letter or underscore, followed by letters, digits, combining marks, '.', '-' or '_'
+func isNCName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '.' || r == '-' || r == 0xB7 || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// xmlEscapeString escapes s for use in XML text and attribute values
+func xmlEscapeString(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,369 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testOrderWSDL is a document/literal WSDL with nested and repeated elements
+const testOrderWSDL = `<?xml version="1.0" encoding="utf-8"?>
+<wsdl:definitions xmlns:wsdl="http://schemas.xmlsoap.org/wsdl/"
+    xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/"
+    xmlns:soap12="http://schemas.xmlsoap.org/wsdl/soap12/"
+    xmlns:s="http://www.w3.org/2001/XMLSchema"
+    xmlns:tns="http://example.com/orders"
+    targetNamespace="http://example.com/orders">
+  <wsdl:types>
+    <s:schema elementFormDefault="qualified" targetNamespace="http://example.com/orders">
+      <s:element name="PlaceOrder">
+        <s:complexType>
+          <s:sequence>
+            <s:element name="CustomerId" type="s:string"/>
+            <s:element name="Address" type="tns:Address"/>
+            <s:element name="Item" type="tns:Item" maxOccurs="unbounded"/>
+          </s:sequence>
+        </s:complexType>
+      </s:element>
+      <s:element name="PlaceOrderResponse">
+        <s:complexType>
+          <s:sequence>
+            <s:element name="OrderId" type="s:string"/>
+          </s:sequence>
+        </s:complexType>
+      </s:element>
+      <s:complexType name="Address">
+        <s:sequence>
+          <s:element name="Street" type="s:string"/>
+          <s:element name="City" type="s:string"/>
+        </s:sequence>
+      </s:complexType>
+      <s:complexType name="Item">
+        <s:sequence>
+          <s:element name="Sku" type="s:string"/>
+          <s:element name="Quantity" type="s:int"/>
+        </s:sequence>
+      </s:complexType>
+    </s:schema>
+  </wsdl:types>
+  <wsdl:message name="PlaceOrderSoapIn">
+    <wsdl:part name="parameters" element="tns:PlaceOrder"/>
+  </wsdl:message>
+  <wsdl:message name="PlaceOrderSoapOut">
+    <wsdl:part name="parameters" element="tns:PlaceOrderResponse"/>
+  </wsdl:message>
+  <wsdl:portType name="OrderServiceSoap">
+    <wsdl:operation name="PlaceOrder">
+      <wsdl:input message="tns:PlaceOrderSoapIn"/>
+      <wsdl:output message="tns:PlaceOrderSoapOut"/>
+    </wsdl:operation>
+  </wsdl:portType>
+  <wsdl:binding name="OrderServiceSoap12" type="tns:OrderServiceSoap">
+    <soap12:binding transport="http://schemas.xmlsoap.org/soap/http"/>
+    <wsdl:operation name="PlaceOrder">
+      <soap12:operation soapAction="http://example.com/orders/PlaceOrder12" style="document"/>
+    </wsdl:operation>
+  </wsdl:binding>
+  <wsdl:binding name="OrderServiceSoap" type="tns:OrderServiceSoap">
+    <soap:binding transport="http://schemas.xmlsoap.org/soap/http"/>
+    <wsdl:operation name="PlaceOrder">
+      <soap:operation soapAction="http://example.com/orders/PlaceOrder" style="document"/>
+    </wsdl:operation>
+  </wsdl:binding>
+</wsdl:definitions>`
+
+// TestParseWSDL verifies operations, SOAP actions and namespaces are discovered
+func TestParseWSDL(t *testing.T) {
+	wsdl, err := ParseWSDL([]byte(testOrderWSDL))
+	if err != nil {
+		t.Fatalf("Failed to parse WSDL: %v", err)
+	}
+
+	if names := wsdl.OperationNames(); len(names) != 1 || names[0] != "PlaceOrder" {
+		t.Fatalf("Expected [PlaceOrder], got %v", names)
+	}
+
+	op, ok := wsdl.Operation("PlaceOrder")
+	if !ok {
+		t.Fatal("Expected PlaceOrder operation")
+	}
+	if op.SOAPAction != "http://example.com/orders/PlaceOrder" {
+		t.Errorf("Expected SOAP 1.1 binding action, got %q", op.SOAPAction)
+	}
+	if op.Namespace != "http://example.com/orders" || op.InputElement != "PlaceOrder" || op.OutputElement != "PlaceOrderResponse" {
+		t.Errorf("Unexpected operation: %+v", op)
+	}
+}
+
+// TestEncodeOperationBody verifies nested maps and slices follow the schema order and namespace
+func TestEncodeOperationBody(t *testing.T) {
+	wsdl, err := ParseWSDL([]byte(testOrderWSDL))
+	if err != nil {
+		t.Fatalf("Failed to parse WSDL: %v", err)
+	}
+	op, _ := wsdl.Operation("PlaceOrder")
+
+	body, err := encodeOperationBody(wsdl, op, "PlaceOrder", "", map[string]any{
+		"Item": []map[string]any{
+			{"Quantity": 2, "Sku": "A&B"},
+			{"Sku": "C", "Quantity": 1},
+		},
+		"Address":    map[string]any{"City": "Springfield", "Street": "1 Main"},
+		"CustomerId": "c-1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode body: %v", err)
+	}
+
+	expected := `<PlaceOrder xmlns="http://example.com/orders">` +
+		`<CustomerId>c-1</CustomerId>` +
+		`<Address><Street>1 Main</Street><City>Springfield</City></Address>` +
+		`<Item><Sku>A&amp;B</Sku><Quantity>2</Quantity></Item>` +
+		`<Item><Sku>C</Sku><Quantity>1</Quantity></Item>` +
+		`</PlaceOrder>`
+	if body != expected {
+		t.Errorf("Unexpected body:\n got: %s\nwant: %s", body, expected)
+	}
+}
+
+// TestEncodeOperationBodyUnqualified verifies unqualified schemas reset the default namespace
+func TestEncodeOperationBodyUnqualified(t *testing.T) {
+	wsdl, err := ParseWSDL([]byte(strings.Replace(testOrderWSDL, `elementFormDefault="qualified"`, "", 1)))
+	if err != nil {
+		t.Fatalf("Failed to parse WSDL: %v", err)
+	}
+	op, _ := wsdl.Operation("PlaceOrder")
+
+	body, err := encodeOperationBody(wsdl, op, "PlaceOrder", "", map[string]any{
+		"CustomerId": "c-1",
+		"Address":    map[string]any{"City": "Springfield"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode body: %v", err)
+	}
+
+	expected := `<PlaceOrder xmlns="http://example.com/orders">` +
+		`<CustomerId xmlns="">c-1</CustomerId>` +
+		`<Address xmlns=""><City>Springfield</City></Address>` +
+		`</PlaceOrder>`
+	if body != expected {
+		t.Errorf("Unexpected body:\n got: %s\nwant: %s", body, expected)
+	}
+}
+
+// TestEncodeOperationBodyNames verifies element names are validated and bytes are base64 encoded
+func TestEncodeOperationBodyNames(t *testing.T) {
+	body, err := encodeOperationBody(nil, nil, "Upload", "urn:files", map[string]any{
+		"Größe": 3,
+		"_data": []byte("<raw>"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode body: %v", err)
+	}
+	expected := `<Upload xmlns="urn:files"><Größe>3</Größe><_data>PHJhdz4=</_data></Upload>`
+	if body != expected {
+		t.Errorf("Unexpected body:\n got: %s\nwant: %s", body, expected)
+	}
+
+	for _, name := range []string{"a b", "x><evil/><y", "ns:el", "1st", ""} {
+		if _, err := encodeOperationBody(nil, nil, "Upload", "urn:files", map[string]any{"Nested": map[string]any{name: 1}}); err == nil {
+			t.Errorf("Expected element name %q to be rejected", name)
+		}
+	}
+	if _, err := encodeOperationBody(nil, nil, "Up load", "urn:files", nil); err == nil {
+		t.Error("Expected an invalid operation element name to be rejected")
+	}
+}
+
+// TestSOAPCallUsesFetchedWSDL verifies the WSDL is fetched once and drives SOAPAction and namespaces
+func TestSOAPCallUsesFetchedWSDL(t *testing.T) {
+	var wsdlFetches int32
+	var lastAction, lastBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&wsdlFetches, 1)
+			w.Write([]byte(testOrderWSDL))
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		lastAction = r.Header.Get("SOAPAction")
+		lastBody = string(data)
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write([]byte(`<?xml version="1.0"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<PlaceOrderResponse xmlns="http://example.com/orders"><OrderId>o-9</OrderId></PlaceOrderResponse>` +
+			`</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Order Service",
+		URL:          server.URL + "/orders.asmx",
+		Type:         EndpointTypeSOAP,
+		Timeout:      5 * time.Second,
+		WSDLUrl:      server.URL + "/orders.asmx?WSDL",
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	for range 2 {
+		result, err := endpoint.Call(map[string]any{
+			"method": "PlaceOrder",
+			"body":   map[string]any{"CustomerId": "c-1"},
+		})
+		if err != nil {
+			t.Fatalf("SOAP call failed: %v", err)
+		}
+		body, _ := result["body"].(map[string]any)
+		if body["OrderId"] != "o-9" {
+			t.Errorf("Expected OrderId o-9, got %v", result["body"])
+		}
+	}
+
+	if fetches := atomic.LoadInt32(&wsdlFetches); fetches != 1 {
+		t.Errorf("Expected WSDL to be fetched once, got %d", fetches)
+	}
+	if lastAction != `"http://example.com/orders/PlaceOrder"` {
+		t.Errorf("Expected SOAPAction from WSDL, got %s", lastAction)
+	}
+	if !strings.Contains(lastBody, `<PlaceOrder xmlns="http://example.com/orders"><CustomerId>c-1</CustomerId></PlaceOrder>`) {
+		t.Errorf("Unexpected request body: %s", lastBody)
+	}
+
+	caller, _ := GetOrCreateAPICaller(endpoint, nil)
+	ops, err := caller.client.(*SOAPClient).Operations(context.Background())
+	if err != nil || len(ops) != 1 {
+		t.Errorf("Expected 1 operation, got %v (%v)", ops, err)
+	}
+}
+
+// TestSOAPWSDLFetchFailure verifies fetch failures are surfaced, cached with a backoff and retried
+func TestSOAPWSDLFetchFailure(t *testing.T) {
+	var wsdlFetches atomic.Int32
+	var available, oversized atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			wsdlFetches.Add(1)
+			switch {
+			case oversized.Load():
+				w.Write([]byte(strings.Repeat(" ", maxWSDLBytes+1)))
+			case available.Load():
+				time.Sleep(50 * time.Millisecond)
+				w.Write([]byte(testOrderWSDL))
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write([]byte(`<?xml version="1.0"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<PlaceOrderResponse xmlns="http://example.com/orders"><OrderId>o-9</OrderId></PlaceOrderResponse>` +
+			`</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Order Service Unavailable WSDL",
+		URL:          server.URL + "/orders.asmx",
+		Type:         EndpointTypeSOAP,
+		Timeout:      5 * time.Second,
+		WSDLUrl:      server.URL + "/orders.asmx?WSDL",
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+	params := map[string]any{"method": "PlaceOrder", "body": map[string]any{"CustomerId": "c-1"}}
+
+	for range 2 {
+		if _, err := endpoint.Call(params); err == nil || !strings.Contains(err.Error(), "HTTP 503") {
+			t.Errorf("Expected the WSDL fetch error, got %v", err)
+		}
+	}
+	if fetches := wsdlFetches.Load(); fetches != 1 {
+		t.Errorf("Expected the failure to be cached, got %d fetches", fetches)
+	}
+
+	// Once the backoff has passed, an oversized WSDL is rejected
+	caller, _ := GetOrCreateAPICaller(endpoint, nil)
+	client := caller.client.(*SOAPClient)
+	expire := func() {
+		client.wsdlMutex.Lock()
+		client.wsdlRetryAt = time.Time{}
+		client.wsdlMutex.Unlock()
+	}
+	expire()
+	oversized.Store(true)
+	if _, err := client.GetWSDL(context.Background()); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected an oversized WSDL to be rejected, got %v", err)
+	}
+
+	// Concurrent callers share a single retry
+	expire()
+	oversized.Store(false)
+	available.Store(true)
+	fetches := wsdlFetches.Load()
+	errs := make(chan error, 4)
+	for range 4 {
+		go func() {
+			_, err := endpoint.Call(params)
+			errs <- err
+		}()
+	}
+	for range 4 {
+		if err := <-errs; err != nil {
+			t.Errorf("Expected the call to succeed after the WSDL recovered, got %v", err)
+		}
+	}
+	if retries := wsdlFetches.Load() - fetches; retries != 1 {
+		t.Errorf("Expected a single WSDL fetch for concurrent callers, got %d", retries)
+	}
+}
+
+// TestSOAPWSDLNotFound verifies raw envelopes and explicit actions are sent without the WSDL
+func TestSOAPWSDLNotFound(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Header.Get("SOAPAction")+" "+string(body))
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write([]byte(`<?xml version="1.0"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<PingResponse><Ok>true</Ok></PingResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	endpoint := Endpoint{
+		FriendlyName: "Test Missing WSDL",
+		URL:          server.URL + "/orders.asmx",
+		Type:         EndpointTypeSOAP,
+		Timeout:      5 * time.Second,
+		WSDLUrl:      server.URL + "/orders.asmx?WSDL",
+	}
+	defer RemoveCallerFromCache(endpoint, nil)
+
+	raw := `<?xml version="1.0"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><Ping/></soap:Body></soap:Envelope>`
+	if _, err := endpoint.Call(map[string]any{"method": "Ping", "body": raw}); err != nil {
+		t.Fatalf("Expected a raw envelope to be sent without the WSDL, got %v", err)
+	}
+	if len(requests) != 1 || !strings.Contains(requests[0], "<Ping/>") {
+		t.Errorf("Expected the raw envelope, got %v", requests)
+	}
+
+	// A map body with an explicit action uses the namespace guessed from the URL
+	if _, err := endpoint.Call(map[string]any{"method": "Ping", "soapAction": "urn:Ping", "body": map[string]any{"Id": "1"}}); err != nil {
+		t.Fatalf("Expected a map body with soapAction to be sent without the WSDL, got %v", err)
+	}
+	if len(requests) != 2 || !strings.HasPrefix(requests[1], `"urn:Ping"`) || !strings.Contains(requests[1], `xmlns="`+server.URL+`/"`) {
+		t.Errorf("Expected the schema-less envelope, got %v", requests)
+	}
+
+	// A map body without an action needs the WSDL
+	if _, err := endpoint.Call(map[string]any{"method": "Ping", "body": map[string]any{"Id": "1"}}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the WSDL fetch error, got %v", err)
+	}
+}
@@ -107,6 +107,9 @@ type ChatMsg struct {
 	StatisticsDoc *StatisticsDoc // Optional statistics document
 }
 
+// Init builds the plugin ConfigContext from the legacy kernel properties map and
+// starts receiverHandler and chatHandler on the kernel channels.
+// It is an adapter over PluginRuntimeFromProperties and PluginRuntime.Start.
 func Init(properties *map[string]any,
 	commonCertPath string,
 	commonKeyPath string,
@@ -116,156 +119,29 @@ func Init(properties *map[string]any,
 	receiverHandler func(chan KernelCmd),
 	chatHandler func(chan *ChatMsg),
 ) (*ConfigContext, error) {
-	if properties == nil ||
-		startHandler == nil ||
-		receiverHandler == nil ||
-		chatHandler == nil {
-		fmt.Fprintln(os.Stderr, "Missing initialization components")
-		return nil, errors.New("missing initialization components")
-	}
-	var logger *log.Logger
-	if _, ok := (*properties)["log"].(*log.Logger); ok {
-		logger = (*properties)["log"].(*log.Logger)
-	}
-
-	var env string
-	var argosId string
-	if e, ok := (*properties)["env"].(string); ok {
-		env = e
-	} else {
-		fmt.Fprintln(os.Stderr, "Missing env from kernel")
-		logger.Println("Missing env from kernel")
-		return nil, errors.New("missing env from kernel")
-	}
-	region := ""
-	if configProp, ok := (*properties)["config"].(*map[string]any); ok {
-		if r, ok := (*configProp)["region"].(string); ok {
-			logger.Println("received region value from kernel")
-			region = r
-		}
-	}
-
-	if len(region) == 0 {
-		if r, ok := (*properties)["region"].(string); ok {
-			logger.Println("received region value from kernel")
-			region = r
+	runtime, err := PluginRuntimeFromProperties(properties, commonCertPath, commonKeyPath, commonPath, dfsKeyHeader)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, err
+	}
+	if runtime.Log != nil {
+		if len(runtime.Region) > 0 {
+			runtime.Log.Println("received region value from kernel")
 		}
+		runtime.Log.Printf("Starting initialization for dataflow: %s\n", runtime.ArgosId)
 	}
 
-	if len(argosId) == 0 {
-		argosId = dfsKeyHeader
-	}
-	logger.Printf("Starting initialization for dataflow: %s\n", argosId)
-
-	var certbytes []byte
-	var keybytes []byte
-	var config_properties *map[string]any
-	if cert, ok := (*properties)[commonCertPath]; ok {
-		certbytes = cert.([]byte)
-	}
-	if key, ok := (*properties)[commonKeyPath]; ok {
-		keybytes = key.([]byte)
-	}
-	if len(commonPath) > 0 {
-		if common, ok := (*properties)[commonPath]; ok {
-			config_properties = common.(*map[string]any)
-		} else {
-			fmt.Fprintln(os.Stderr, "Missing config components")
-			return nil, errors.New("missing config components")
-		}
-	} else {
-		config_properties = &map[string]any{}
-	}
-
-	var configCerts *map[string][]byte = &map[string][]byte{}
-
-	if len(certbytes) > 0 && len(keybytes) > 0 {
-		(*configCerts)[commonCertPath] = certbytes
-		(*configCerts)[commonKeyPath] = keybytes
-	}
-
-	configContext := &ConfigContext{
-		Env:         env,
-		Region:      region,
-		Config:      config_properties,
-		Start:       startHandler,
-		ArgosId:     argosId,
-		ConfigCerts: configCerts,
-		Log:         logger,
-	}
-
-	if channels, ok := (*properties)[PLUGIN_EVENT_CHANNELS_MAP_KEY]; ok {
-		if chans, ok := channels.(map[string]any); ok {
-			if bchan, ok := chans[CHAT_BROADCAST_CHANNEL].(*chan *ChatMsg); ok {
-				configContext.Log.Println("Chat broadcast channel initialized.")
-				configContext.ChatBroadcastChan = bchan
-			} else {
-				configContext.Log.Println("Unsupported broadcast channel passed")
-				return nil, errors.New("unsupported broadcast channel passed")
-			}
-			if rchan, ok := chans[PLUGIN_CHANNEL_EVENT_IN].(map[string]any); ok {
-				if rc, ok := rchan[CMD_CHANNEL].(*chan KernelCmd); ok && rc != nil {
-					configContext.Log.Println("Command Receiver initialized.")
-					configContext.CmdReceiverChan = rc
-					go receiverHandler(*rc)
-				} else {
-					configContext.Log.Println("Unsupported command receiving channel passed")
-					return nil, errors.New("unsupported command receiving channel passed")
-				}
-				if cr, ok := rchan[CHAT_CHANNEL].(*chan *ChatMsg); ok && cr != nil {
-					configContext.Log.Println("Chat Receiver initialized.")
-					configContext.ChatReceiverChan = cr
-					go chatHandler(*cr)
-				} else {
-					configContext.Log.Println("Unsupported chat message receiving channel passed")
-					return nil, errors.New("unsupported chat message receiving channel passed")
-				}
-			} else {
-				configContext.Log.Println("No receiving channel passed")
-				return nil, errors.New("no receiving channel passed")
-			}
-			if schan, ok := chans[PLUGIN_CHANNEL_EVENT_OUT].(map[string]any); ok {
-				if sc, ok := schan[ERROR_CHANNEL].(*chan error); ok && sc != nil {
-					configContext.Log.Println("Error Sender initialized")
-					configContext.ErrorChan = sc
-				} else {
-					configContext.Log.Println("Unsupported error sending channel passed")
-					return nil, errors.New("unsupported error sending channel passed")
-				}
-				if ttdichan, ok := schan[DATA_FLOW_STAT_CHANNEL].(*chan *TTDINode); ok {
-					configContext.Log.Println("Data flow statistics channel initialized.")
-					configContext.DfsChan = ttdichan
-				} else {
-					configContext.Log.Println("Unsupported dataflow statistics sending channel passedUnsupported dataflow statistics sending channel passed")
-					return nil, errors.New("unsupported dataflow statistics sending channel passed")
-				}
-				if cmdsender, ok := schan[CMD_CHANNEL].(*chan KernelCmd); ok {
-					configContext.Log.Println("Command status sending channel initialized.")
-					configContext.CmdSenderChan = cmdsender
-				} else {
-					configContext.Log.Println("Unsupported command status sending channel passed")
-					return nil, errors.New("unsupported command status sending channel passed")
-				}
-				if chsender, ok := schan[CHAT_CHANNEL].(*chan *ChatMsg); ok {
-					configContext.Log.Println("Chat message sending channel initialized.")
-					configContext.ChatSenderChan = chsender
-				} else {
-					configContext.Log.Println("Unsupported chat message sending channel passed")
-					return nil, errors.New("unsupported chat message sending channel passed")
-				}
-			} else {
-				configContext.Log.Println("No sending channels passed")
-				return nil, errors.New("no sending channels passed")
-			}
-		} else {
-			configContext.Log.Println("No channels passed")
-			return nil, errors.New("no channels passed")
-		}
+	configContext, err := runtime.WithHandlers(startHandler, receiverHandler, chatHandler).Start()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, SanitizeForLogging(err.Error()))
+		return nil, err
 	}
-	configContext.Log.Println("Successfully initialized plugin")
 	return configContext, nil
 }
 
+// InitPost builds the ConfigContext of a plugin started after kernel boot from the
+// legacy properties map and passes it to PostInit.
+// Missing channels are logged but, as before, do not fail the initialization.
 func InitPost(pluginName string,
 	properties *map[string]any,
 	PostInit func(*ConfigContext),
@@ -274,82 +150,25 @@ func InitPost(pluginName string,
 		fmt.Fprintln(os.Stderr, "Missing initialization components")
 		return nil, errors.New("missing initialization component")
 	}
-	var logger *log.Logger
-	if _, ok := (*properties)["log"].(*log.Logger); ok {
-		logger = (*properties)["log"].(*log.Logger)
-	}
-
-	configContext := &ConfigContext{
-		Config:      properties,
-		ConfigCerts: &map[string][]byte{},
-		Log:         logger,
-	}
+	logger, _ := (*properties)["log"].(*log.Logger)
+	runtime := NewPluginRuntime(pluginName).
+		WithConfig(properties).
+		WithLogger(logger).
+		WithOptionalBroadcast()
 
 	if channels, ok := (*properties)[PLUGIN_EVENT_CHANNELS_MAP_KEY]; ok {
-		if chans, ok := channels.(map[string]any); ok {
-			if rchan, ok := chans[PLUGIN_CHANNEL_EVENT_IN].(map[string]any); ok {
-				if cmdreceiver, ok := rchan[CMD_CHANNEL].(*chan KernelCmd); ok {
-					configContext.CmdReceiverChan = cmdreceiver
-					configContext.Log.Println("Command Receiver initialized.")
-				} else {
-					configContext.Log.Println("Unsupported receiving channel passed")
-					goto postinit
-				}
-
-				if cr, ok := rchan[CHAT_CHANNEL].(*chan *ChatMsg); ok {
-					configContext.Log.Println("Chat Receiver initialized.")
-					configContext.ChatReceiverChan = cr
-					//					go chatHandler(*cr)
-				} else {
-					configContext.Log.Println("Unsupported chat message receiving channel passed")
-					goto postinit
-				}
-
-			} else {
-				configContext.Log.Println("No event in receiving channel passed")
-				goto postinit
-			}
-			if schan, ok := chans[PLUGIN_CHANNEL_EVENT_OUT].(map[string]any); ok {
-				if cmdsender, ok := schan[CMD_CHANNEL].(*chan KernelCmd); ok {
-					configContext.CmdSenderChan = cmdsender
-					configContext.Log.Println("Command Sender initialized.")
-				} else {
-					configContext.Log.Println("Unsupported receiving channel passed")
-					goto postinit
-				}
-
-				if cs, ok := schan[CHAT_CHANNEL].(*chan *ChatMsg); ok {
-					configContext.Log.Println("Chat Sender initialized.")
-					configContext.ChatSenderChan = cs
-				} else {
-					configContext.Log.Println("Unsupported chat message receiving channel passed")
-					goto postinit
-				}
-
-				if dfsc, ok := schan[DATA_FLOW_STAT_CHANNEL].(*chan *TTDINode); ok {
-					configContext.Log.Println("DFS Sender initialized.")
-					configContext.DfsChan = dfsc
-				} else {
-					configContext.Log.Println("Unsupported DFS sending channel passed")
-					goto postinit
-				}
-
-				if sc, ok := schan[ERROR_CHANNEL].(*chan error); ok {
-					configContext.ErrorChan = sc
-				} else {
-					configContext.Log.Println("Unsupported sending channel passed")
-					goto postinit
-				}
-			} else {
-				configContext.Log.Println("No event out channel passed")
-				goto postinit
-			}
-		} else {
-			configContext.Log.Println("No event channels passed")
-			goto postinit
+		pluginChannels, err := ChannelsFromMap(channels)
+		if err != nil {
+			// Report every channel as missing
+			pluginChannels = &PluginChannels{}
 		}
+		runtime.WithChannels(pluginChannels)
+	}
+
+	configContext := runtime.ConfigContext()
+	if err := runtime.Validate(); err != nil {
+		configContext.Log.Println(SanitizeForLogging(err.Error()))
 	}
-postinit:
 	PostInit(configContext)
 	return configContext, nil
 }
@@ -0,0 +1,306 @@
+package core
+
+import (
+	"errors"
+	"log"
+	"strings"
+)
+
+// PluginChannels is the typed bundle of channels connecting a plugin to the kernel
+// It replaces the nested map stored under PLUGIN_EVENT_CHANNELS_MAP_KEY.
+type PluginChannels struct {
+	CmdReceiver   *chan KernelCmd // Kernel commands to the plugin (PluginChannelEventIn/CommandChannel)
+	ChatReceiver  *chan *ChatMsg  // Chat messages to the plugin (PluginChannelEventIn/ChatChannel)
+	CmdSender     *chan KernelCmd // Command status from the plugin (PluginChannelEventOut/CommandChannel)
+	ChatSender    *chan *ChatMsg  // Chat messages from the plugin (PluginChannelEventOut/ChatChannel)
+	ChatBroadcast *chan *ChatMsg  // Chat broadcasts (ChatBroadcastChannel)
+	ErrorChan     *chan error     // Errors from the plugin (PluginChannelEventOut/ErrorChannel)
+	DfsChan       *chan *TTDINode // Data flow statistics from the plugin (PluginChannelEventOut/DataFlowStatisticsChannel)
+}
+
+// MissingChannelsError lists every required plugin channel that is missing or of the wrong type
+type MissingChannelsError struct {
+	Missing []string // Legacy map paths of the missing channels, e.g. "PluginChannelEventIn/CommandChannel"
+}
+
+func (e *MissingChannelsError) Error() string {
+	return "missing plugin channels: " + strings.Join(e.Missing, ", ")
+}
+
+// channelPath returns the legacy map path of a channel
+func channelPath(direction string, channel string) string {
+	if direction == "" {
+		return channel
+	}
+	return direction + "/" + channel
+}
+
+// missing returns the legacy map paths of the nil channels
+func (c *PluginChannels) missing(requireBroadcast bool) []string {
+	var missing []string
+	if requireBroadcast && c.ChatBroadcast == nil {
+		missing = append(missing, channelPath("", CHAT_BROADCAST_CHANNEL))
+	}
+	for _, channel := range []struct {
+		direction string
+		name      string
+		isSet     bool
+	}{
+		{PLUGIN_CHANNEL_EVENT_IN, CMD_CHANNEL, c.CmdReceiver != nil},
+		{PLUGIN_CHANNEL_EVENT_IN, CHAT_CHANNEL, c.ChatReceiver != nil},
+		{PLUGIN_CHANNEL_EVENT_OUT, ERROR_CHANNEL, c.ErrorChan != nil},
+		{PLUGIN_CHANNEL_EVENT_OUT, DATA_FLOW_STAT_CHANNEL, c.DfsChan != nil},
+		{PLUGIN_CHANNEL_EVENT_OUT, CMD_CHANNEL, c.CmdSender != nil},
+		{PLUGIN_CHANNEL_EVENT_OUT, CHAT_CHANNEL, c.ChatSender != nil},
+	} {
+		if !channel.isSet {
+			missing = append(missing, channelPath(channel.direction, channel.name))
+		}
+	}
+	return missing
+}
+
+// ChannelsFromMap converts the legacy channel map stored under PLUGIN_EVENT_CHANNELS_MAP_KEY
+// Channels that are absent or of the wrong type are left nil and reported by validation.
+func ChannelsFromMap(channels any) (*PluginChannels, error) {
+	chans, ok := channels.(map[string]any)
+	if !ok {
+		return nil, errors.New("no channels passed")
+	}
+	pluginChannels := &PluginChannels{}
+	pluginChannels.ChatBroadcast, _ = chans[CHAT_BROADCAST_CHANNEL].(*chan *ChatMsg)
+	if rchan, ok := chans[PLUGIN_CHANNEL_EVENT_IN].(map[string]any); ok {
+		pluginChannels.CmdReceiver, _ = rchan[CMD_CHANNEL].(*chan KernelCmd)
+		pluginChannels.ChatReceiver, _ = rchan[CHAT_CHANNEL].(*chan *ChatMsg)
+	}
+	if schan, ok := chans[PLUGIN_CHANNEL_EVENT_OUT].(map[string]any); ok {
+		pluginChannels.ErrorChan, _ = schan[ERROR_CHANNEL].(*chan error)
+		pluginChannels.DfsChan, _ = schan[DATA_FLOW_STAT_CHANNEL].(*chan *TTDINode)
+		pluginChannels.CmdSender, _ = schan[CMD_CHANNEL].(*chan KernelCmd)
+		pluginChannels.ChatSender, _ = schan[CHAT_CHANNEL].(*chan *ChatMsg)
+	}
+	return pluginChannels, nil
+}
+
+// Map returns the channels as the legacy map stored under PLUGIN_EVENT_CHANNELS_MAP_KEY
+// for kernels passing them to plugins that still use Init or InitPost.
+func (c *PluginChannels) Map() map[string]any {
+	return map[string]any{
+		CHAT_BROADCAST_CHANNEL: c.ChatBroadcast,
+		PLUGIN_CHANNEL_EVENT_IN: map[string]any{
+			CMD_CHANNEL:  c.CmdReceiver,
+			CHAT_CHANNEL: c.ChatReceiver,
+		},
+		PLUGIN_CHANNEL_EVENT_OUT: map[string]any{
+			ERROR_CHANNEL:          c.ErrorChan,
+			DATA_FLOW_STAT_CHANNEL: c.DfsChan,
+			CMD_CHANNEL:            c.CmdSender,
+			CHAT_CHANNEL:           c.ChatSender,
+		},
+	}
+}
+
+// PluginRuntime builds the ConfigContext of a plugin from typed parts
+//
+//	configContext, err := core.NewPluginRuntime("myplugin").
+//		WithEnv(env, region).
+//		WithConfig(config).
+//		WithChannels(channels).
+//		WithHandlers(start, receiver, chat).
+//		Start()
+type PluginRuntime struct {
+	Name            string
+	Env             string
+	Region          string
+	ArgosId         string
+	Config          *map[string]any
+	ConfigCerts     *map[string][]byte
+	Log             *log.Logger
+	Channels        *PluginChannels // nil if the kernel passed no channels
+	StartHandler    func(string)
+	ReceiverHandler func(chan KernelCmd)
+	ChatHandler     func(chan *ChatMsg)
+
+	optionalBroadcast bool
+}
+
+// NewPluginRuntime creates a runtime for the plugin name
+func NewPluginRuntime(name string) *PluginRuntime {
+	return &PluginRuntime{Name: name}
+}
+
+// WithEnv sets the environment and region being processed
+func (r *PluginRuntime) WithEnv(env string, region string) *PluginRuntime {
+	r.Env = env
+	r.Region = region
+	return r
+}
+
+// WithArgosId sets the identifier for data flow statistics
+func (r *PluginRuntime) WithArgosId(argosId string) *PluginRuntime {
+	r.ArgosId = argosId
+	return r
+}
+
+// WithConfig sets the plugin configuration
+func (r *PluginRuntime) WithConfig(config *map[string]any) *PluginRuntime {
+	r.Config = config
+	return r
+}
+
+// WithCerts sets the certificates passed to the plugin
+func (r *PluginRuntime) WithCerts(configCerts *map[string][]byte) *PluginRuntime {
+	r.ConfigCerts = configCerts
+	return r
+}
+
+// WithLogger sets the plugin logger
+func (r *PluginRuntime) WithLogger(logger *log.Logger) *PluginRuntime {
+	r.Log = logger
+	return r
+}
+
+// WithChannels sets the channels to the kernel
+func (r *PluginRuntime) WithChannels(channels *PluginChannels) *PluginRuntime {
+	r.Channels = channels
+	return r
+}
+
+// WithOptionalBroadcast allows channels without a chat broadcast channel
+func (r *PluginRuntime) WithOptionalBroadcast() *PluginRuntime {
+	r.optionalBroadcast = true
+	return r
+}
+
+// WithHandlers sets the start, kernel command and chat message handlers run by Start
+func (r *PluginRuntime) WithHandlers(start func(string), receiver func(chan KernelCmd), chat func(chan *ChatMsg)) *PluginRuntime {
+	r.StartHandler = start
+	r.ReceiverHandler = receiver
+	r.ChatHandler = chat
+	return r
+}
+
+// Validate reports every missing part of the runtime at once
+// Channels are optional as a whole, but a bundle must be complete.
+func (r *PluginRuntime) Validate() error {
+	return r.validate(false)
+}
+
+// validate collects the problems of the runtime; handlers and env are required for Start
+func (r *PluginRuntime) validate(starting bool) error {
+	var errs []error
+	if starting {
+		if r.StartHandler == nil || r.ReceiverHandler == nil || r.ChatHandler == nil {
+			errs = append(errs, errors.New("missing initialization components"))
+		}
+		if r.Env == "" {
+			errs = append(errs, errors.New("missing env from kernel"))
+		}
+	}
+	if r.Config == nil {
+		errs = append(errs, errors.New("missing config components"))
+	}
+	if r.Channels != nil {
+		if missing := r.Channels.missing(!r.optionalBroadcast); len(missing) > 0 {
+			errs = append(errs, &MissingChannelsError{Missing: missing})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ConfigContext returns the ConfigContext of the runtime without validating it
+func (r *PluginRuntime) ConfigContext() *ConfigContext {
+	logger := r.Log
+	if logger == nil {
+		logger = log.Default()
+	}
+	configCerts := r.ConfigCerts
+	if configCerts == nil {
+		configCerts = &map[string][]byte{}
+	}
+	configContext := &ConfigContext{
+		Config:      r.Config,
+		Env:         r.Env,
+		Region:      r.Region,
+		Start:       r.StartHandler,
+		ArgosId:     r.ArgosId,
+		ConfigCerts: configCerts,
+		Log:         logger,
+	}
+	if r.Channels != nil {
+		configContext.CmdReceiverChan = r.Channels.CmdReceiver
+		configContext.ChatReceiverChan = r.Channels.ChatReceiver
+		configContext.CmdSenderChan = r.Channels.CmdSender
+		configContext.ChatSenderChan = r.Channels.ChatSender
+		configContext.ChatBroadcastChan = r.Channels.ChatBroadcast
+		configContext.ErrorChan = r.Channels.ErrorChan
+		configContext.DfsChan = r.Channels.DfsChan
+	}
+	return configContext
+}
+
+// Start validates the runtime, starts the command and chat handlers on the
+// receiving channels and returns the plugin ConfigContext
+func (r *PluginRuntime) Start() (*ConfigContext, error) {
+	if err := r.validate(true); err != nil {
+		if r.Log != nil {
+			r.Log.Println(SanitizeForLogging(err.Error()))
+		}
+		return nil, err
+	}
+	configContext := r.ConfigContext()
+	if r.Channels != nil {
+		go r.ReceiverHandler(*r.Channels.CmdReceiver)
+		go r.ChatHandler(*r.Channels.ChatReceiver)
+	}
+	configContext.Log.Println("Successfully initialized plugin")
+	return configContext, nil
+}
+
+// PluginRuntimeFromProperties builds a runtime from the legacy kernel properties map
+// Missing parts are left unset and reported by Validate or Start.
+func PluginRuntimeFromProperties(properties *map[string]any,
+	commonCertPath string,
+	commonKeyPath string,
+	commonPath string,
+	dfsKeyHeader string,
+) (*PluginRuntime, error) {
+	if properties == nil {
+		return nil, errors.New("missing initialization components")
+	}
+	runtime := NewPluginRuntime("").WithArgosId(dfsKeyHeader)
+	runtime.Log, _ = (*properties)["log"].(*log.Logger)
+	runtime.Env, _ = (*properties)["env"].(string)
+
+	// A region in the config takes precedence over the kernel property
+	if configProp, ok := (*properties)["config"].(*map[string]any); ok {
+		runtime.Region, _ = (*configProp)["region"].(string)
+	}
+	if len(runtime.Region) == 0 {
+		runtime.Region, _ = (*properties)["region"].(string)
+	}
+
+	if len(commonPath) > 0 {
+		runtime.Config, _ = (*properties)[commonPath].(*map[string]any)
+	} else {
+		runtime.Config = &map[string]any{}
+	}
+
+	configCerts := map[string][]byte{}
+	certbytes, _ := (*properties)[commonCertPath].([]byte)
+	keybytes, _ := (*properties)[commonKeyPath].([]byte)
+	if len(certbytes) > 0 && len(keybytes) > 0 {
+		configCerts[commonCertPath] = certbytes
+		configCerts[commonKeyPath] = keybytes
+	}
+	runtime.ConfigCerts = &configCerts
+
+	if channels, ok := (*properties)[PLUGIN_EVENT_CHANNELS_MAP_KEY]; ok {
+		pluginChannels, err := ChannelsFromMap(channels)
+		if err != nil {
+			return nil, err
+		}
+		runtime.Channels = pluginChannels
+	}
+	return runtime, nil
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testChannels returns a complete channel bundle
+func testChannels() *PluginChannels {
+	cmdReceiver := make(chan KernelCmd, 1)
+	chatReceiver := make(chan *ChatMsg, 1)
+	cmdSender := make(chan KernelCmd, 1)
+	chatSender := make(chan *ChatMsg, 1)
+	chatBroadcast := make(chan *ChatMsg, 1)
+	errorChan := make(chan error, 1)
+	dfsChan := make(chan *TTDINode, 1)
+	return &PluginChannels{
+		CmdReceiver:   &cmdReceiver,
+		ChatReceiver:  &chatReceiver,
+		CmdSender:     &cmdSender,
+		ChatSender:    &chatSender,
+		ChatBroadcast: &chatBroadcast,
+		ErrorChan:     &errorChan,
+		DfsChan:       &dfsChan,
+	}
+}
+
+// TestPluginRuntimeStart verifies Start wires the channels and runs the handlers
+func TestPluginRuntimeStart(t *testing.T) {
+	channels := testChannels()
+	commands := make(chan KernelCmd, 1)
+	configContext, err := NewPluginRuntime("test").
+		WithEnv("dev", "west").
+		WithConfig(&map[string]any{}).
+		WithLogger(log.New(io.Discard, "", 0)).
+		WithChannels(channels).
+		WithHandlers(func(string) {}, func(in chan KernelCmd) { commands <- <-in }, func(chan *ChatMsg) {}).
+		Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if configContext.Env != "dev" || configContext.Region != "west" || configContext.DfsChan != channels.DfsChan || configContext.ChatBroadcastChan != channels.ChatBroadcast {
+		t.Errorf("Unexpected config context: %+v", configContext)
+	}
+
+	*channels.CmdReceiver <- KernelCmd{PluginName: "test", Command: PLUGIN_EVENT_START}
+	select {
+	case cmd := <-commands:
+		if cmd.Command != PLUGIN_EVENT_START {
+			t.Errorf("Unexpected command %+v", cmd)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the receiver handler to be started")
+	}
+}
+
+// TestPluginRuntimeValidation verifies all missing parts are reported at once
+func TestPluginRuntimeValidation(t *testing.T) {
+	channels := testChannels()
+	channels.CmdSender = nil
+	channels.ChatBroadcast = nil
+
+	_, err := NewPluginRuntime("test").WithChannels(channels).Start()
+	var missingErr *MissingChannelsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("Expected *MissingChannelsError, got %v", err)
+	}
+	expected := []string{CHAT_BROADCAST_CHANNEL, PLUGIN_CHANNEL_EVENT_OUT + "/" + CMD_CHANNEL}
+	if !reflect.DeepEqual(missingErr.Missing, expected) {
+		t.Errorf("Expected missing %v, got %v", expected, missingErr.Missing)
+	}
+	for _, message := range []string{"missing initialization components", "missing env from kernel", "missing config components"} {
+		if !slices.Contains(strings.Split(err.Error(), "\n"), message) {
+			t.Errorf("Expected %q in %v", message, err)
+		}
+	}
+
+	// Post-init runtimes need neither handlers nor a broadcast channel
+	channels.CmdSender = testChannels().CmdSender
+	runtime := NewPluginRuntime("test").WithConfig(&map[string]any{}).WithChannels(channels).WithOptionalBroadcast()
+	if err := runtime.Validate(); err != nil {
+		t.Errorf("Expected a valid post-init runtime, got %v", err)
+	}
+}
+
+// TestLegacyInitAdapters verifies Init and InitPost accept the legacy channel map
+func TestLegacyInitAdapters(t *testing.T) {
+	channels := testChannels()
+	properties := map[string]any{
+		"env":                         "qa",
+		"region":                      "east",
+		"log":                         log.New(io.Discard, "", 0),
+		"cert.crt":                    []byte("cert"),
+		"key.key":                     []byte("key"),
+		"common":                      &map[string]any{"setting": "value"},
+		PLUGIN_EVENT_CHANNELS_MAP_KEY: channels.Map(),
+	}
+	noop := func(chan KernelCmd) {}
+	configContext, err := Init(&properties, "cert.crt", "key.key", "common", "argos", func(string) {}, noop, func(chan *ChatMsg) {})
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if configContext.Region != "east" || configContext.ArgosId != "argos" || (*configContext.Config)["setting"] != "value" ||
+		string((*configContext.ConfigCerts)["cert.crt"]) != "cert" || configContext.ChatSenderChan != channels.ChatSender {
+		t.Errorf("Unexpected config context: %+v", configContext)
+	}
+
+	// Init fails on an incomplete bundle; InitPost still runs PostInit with the channels it got
+	delete(properties[PLUGIN_EVENT_CHANNELS_MAP_KEY].(map[string]any)[PLUGIN_CHANNEL_EVENT_OUT].(map[string]any), ERROR_CHANNEL)
+	if _, err := Init(&properties, "cert.crt", "key.key", "common", "argos", func(string) {}, noop, func(chan *ChatMsg) {}); err == nil {
+		t.Error("Expected Init to fail without an error channel")
+	}
+	var posted *ConfigContext
+	if _, err := InitPost("test", &properties, func(c *ConfigContext) { posted = c }); err != nil {
+		t.Fatalf("InitPost failed: %v", err)
+	}
+	if posted == nil || posted.ErrorChan != nil || posted.CmdReceiverChan != channels.CmdReceiver || posted.DfsChan != channels.DfsChan {
+		t.Errorf("Expected PostInit with the remaining channels, got %+v", posted)
+	}
+}
@@ -7,16 +7,14 @@ require (
 	github.com/go-git/go-billy/v5 v5.9.0
 	github.com/orcaman/concurrent-map/v2 v2.0.1
 	github.com/trimble-oss/tierceron-nute-core v1.0.7
+	golang.org/x/net v0.56.0
 	golang.org/x/sys v0.47.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478
 	google.golang.org/grpc v1.82.1
 	google.golang.org/protobuf v1.36.11
 )
 
-require (
-	golang.org/x/net v0.56.0 // indirect
-	golang.org/x/text v0.40.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
-)
+require golang.org/x/text v0.40.0 // indirect
 
 replace (
 	go.opentelemetry.io/otel => go.opentelemetry.io/otel v1.43.0